@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/authz"
+)
+
+// authorize checks that the caller is signed in and their role grants perm.
+// It writes the error response and returns false otherwise.
+func authorize(w http.ResponseWriter, r *http.Request, policy *authz.Policy, jwtSecret, perm string) (uuid.UUID, bool) {
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return uuid.Nil, false
+	}
+
+	allowed, err := policy.Can(r.Context(), userID, perm)
+	if err != nil {
+		logError(r, "failed to check permissions", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to check permissions"})
+		return uuid.Nil, false
+	}
+	if !allowed {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Forbidden"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// RequirePermission only lets callers whose role grants perm reach next
+func RequirePermission(policy *authz.Policy, jwtSecret, perm string, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := authorize(w, r, policy, jwtSecret, perm); !ok {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireClientCert only lets requests that presented a verified TLS client
+// certificate reach next
+func RequireClientCert(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Client certificate required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
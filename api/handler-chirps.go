@@ -1,15 +1,20 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/xaitan80/go-server/internal/auth"
 	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/metrics"
+	"github.com/xaitan80/go-server/internal/notifications"
+	"github.com/xaitan80/go-server/internal/outbox"
 )
 
 // List of bad words to filter
@@ -21,20 +26,44 @@ var badWords = []string{
 
 // Request struct for incoming JSON
 type chirpRequest struct {
-	Body string `json:"body"`
+	Body        string       `json:"body"`
+	ReplyTo     string       `json:"reply_to,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Visibility  string       `json:"visibility,omitempty"`
+	ScheduledAt *time.Time   `json:"scheduled_at,omitempty"`
+	MediaIDs    []string     `json:"media_ids,omitempty"`
+	Poll        *pollRequest `json:"poll,omitempty"`
 }
 
 // Response struct for JSON
 type ChirpResponse struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    string    `json:"user_id"`
+	ID           string               `json:"id"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	Body         string               `json:"body"`
+	UserID       string               `json:"user_id"`
+	AuthorHandle string               `json:"author_handle,omitempty"`
+	ReplyToID    string               `json:"reply_to_id,omitempty"`
+	Visibility   string               `json:"visibility"`
+	Status       string               `json:"status,omitempty"`
+	ScheduledAt  *time.Time           `json:"scheduled_at,omitempty"`
+	PublishError string               `json:"publish_error,omitempty"`
+	PinnedAt     *time.Time           `json:"pinned_at,omitempty"`
+	Attachments  []AttachmentResponse `json:"attachments,omitempty"`
+	Poll         *PollResponse        `json:"poll,omitempty"`
 }
 
+// Chirp statuses stored in chirps.status
+const (
+	chirpStatusDraft     = "draft"
+	chirpStatusScheduled = "scheduled"
+	chirpStatusPublished = "published"
+)
+
 // ChirpsHandler handles POST /api/chirps
-func ChirpsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+// With status "draft" or "scheduled" (plus scheduled_at) the chirp is saved
+// unpublished; see /api/drafts. Bodies longer than maxLength are rejected.
+func ChirpsHandler(db *sql.DB, queries *database.Queries, jwtSecret string, maxLength int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -56,6 +85,18 @@ func ChirpsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc
 			return
 		}
 
+		user, err := queries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid token"})
+			return
+		}
+		if isSuspended(user) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Account suspended until " + user.SuspendedUntil.Time.Format(time.RFC3339)})
+			return
+		}
+
 		var req chirpRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -63,41 +104,153 @@ func ChirpsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc
 			return
 		}
 
-		if len(req.Body) > 140 {
+		if len(req.Body) > maxLength {
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp is too long"})
 			return
 		}
 
-		// Filter bad words
-		words := strings.Fields(req.Body)
-		for i, w := range words {
-			lower := strings.ToLower(w)
-			for _, bad := range badWords {
-				if lower == bad {
-					words[i] = "****"
+		mediaIDs, err := parseMediaIDs(req.MediaIDs)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid media_ids"})
+			return
+		}
+
+		if req.Status == "" {
+			req.Status = chirpStatusPublished
+		}
+		scheduledAt, errMsg := validateSchedule(req.Status, req.ScheduledAt)
+		if errMsg != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: errMsg})
+			return
+		}
+
+		switch req.Visibility {
+		case "":
+			req.Visibility = chirpVisibilityPublic
+		case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityMentioned:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid visibility"})
+			return
+		}
+
+		var pollLabels []string
+		if req.Poll != nil {
+			publishAt := time.Now()
+			if scheduledAt.Valid {
+				publishAt = scheduledAt.Time
+			}
+			pollLabels, errMsg = validatePoll(req.Poll, publishAt)
+			if errMsg != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: errMsg})
+				return
+			}
+		}
+
+		// Replies must point at an existing chirp
+		var replyToID uuid.NullUUID
+		var parentAuthorID uuid.UUID
+		if req.ReplyTo != "" {
+			parentID, err := uuid.Parse(req.ReplyTo)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid reply_to"})
+				return
+			}
+			parent, err := visibleChirp(r.Context(), queries, parentID, uuid.NullUUID{UUID: userID, Valid: true})
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					w.WriteHeader(http.StatusNotFound)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+				} else {
+					logError(r, "failed to fetch chirp", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirp"})
 				}
+				return
 			}
+
+			replyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+			parentAuthorID = parent.UserID
 		}
-		cleaned := strings.Join(words, " ")
 
-		chirp, err := queries.CreateChirp(r.Context(), database.CreateChirpParams{
-			Body:   cleaned,
-			UserID: userID,
-		})
+		cleaned := censorChirp(req.Body)
+
+		// Drafts and scheduled chirps have no side effects until published
+		var resp ChirpResponse
+		if req.Status != chirpStatusPublished {
+			err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+				chirp, err := q.CreateUnpublishedChirp(r.Context(), database.CreateUnpublishedChirpParams{
+					Body:        cleaned,
+					UserID:      userID,
+					ReplyToID:   replyToID,
+					Status:      req.Status,
+					ScheduledAt: scheduledAt,
+					Visibility:  req.Visibility,
+				})
+				if err != nil {
+					return err
+				}
+				if err := attachMedia(r.Context(), q, chirp, mediaIDs); err != nil {
+					return err
+				}
+				if req.Poll != nil {
+					if err := createPoll(r.Context(), q, chirp.ID, req.Poll, pollLabels); err != nil {
+						return err
+					}
+				}
+
+				resps := []ChirpResponse{chirpToResponse(chirp)}
+				if err := attachChirpMedia(r.Context(), q, resps); err != nil {
+					return err
+				}
+				if err := attachChirpPolls(r.Context(), q, resps, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+					return err
+				}
+				resp = resps[0]
+				return nil
+			})
+		} else {
+			// Create the chirp, its notifications and its outbox event atomically
+			err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+				chirp, err := q.CreateChirp(r.Context(), database.CreateChirpParams{
+					Body:       cleaned,
+					UserID:     userID,
+					ReplyToID:  replyToID,
+					Visibility: req.Visibility,
+				})
+				if err != nil {
+					return err
+				}
+				if err := attachMedia(r.Context(), q, chirp, mediaIDs); err != nil {
+					return err
+				}
+				if req.Poll != nil {
+					if err := createPoll(r.Context(), q, chirp.ID, req.Poll, pollLabels); err != nil {
+						return err
+					}
+				}
+
+				resp, err = publishChirp(r.Context(), q, chirp, parentAuthorID)
+				return err
+			})
+		}
+		if errors.Is(err, errInvalidMedia) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid media_ids"})
+			return
+		}
 		if err != nil {
+			logError(r, "failed to create chirp", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create chirp"})
 			return
 		}
-
-		resp := ChirpResponse{
-			ID:        chirp.ID.String(),
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID.String(),
-		}
+		metrics.ChirpsCreated.WithLabelValues(req.Status).Inc()
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -106,8 +259,13 @@ func ChirpsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc
 }
 
 // GetAllChirpsHandler handles GET /api/chirps
-// GetAllChirpsHandler handles GET /api/chirps
-func GetAllChirpsHandler(DB *database.Queries) http.HandlerFunc {
+// Supports author_id, sort (asc or desc) and optional cursor/limit pagination;
+// the cursor for the next page is returned in the X-Next-Cursor header.
+// With author_id and pinned=first the author's pinned chirps lead the first
+// page and are left out of the pages after it.
+// Authenticated viewers don't see chirps across blocks or from muted authors;
+// requests with an invalid or expired token are served as anonymous.
+func GetAllChirpsHandler(DB *database.Queries, jwtSecret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -115,53 +273,235 @@ func GetAllChirpsHandler(DB *database.Queries) http.HandlerFunc {
 			return
 		}
 
-		sortOrder := r.URL.Query().Get("sort")
-		if sortOrder == "" {
-			sortOrder = "asc"
-		}
+		// The timeline is public, so a stale or invalid token just makes
+		// the request anonymous instead of failing it
+		viewerID, _ := optionalViewer(r, jwtSecret)
 
-		authorIDStr := r.URL.Query().Get("author_id")
-		var chirps []database.Chirp
-		var err error
+		params := database.ListFeedChirpsParams{ViewerID: viewerID}
+		if !parseFeedPage(w, r, &params) {
+			return
+		}
 
-		if authorIDStr != "" {
+		if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
 			authorID, parseErr := uuid.Parse(authorIDStr)
 			if parseErr != nil {
 				w.WriteHeader(http.StatusBadRequest)
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid author_id"})
 				return
 			}
-			chirps, err = DB.GetChirpsByAuthorID(r.Context(), authorID)
-		} else {
-			chirps, err = DB.ListChirps(r.Context())
+			params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
 		}
 
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+		switch r.URL.Query().Get("pinned") {
+		case "":
+		case "first":
+			if !params.AuthorID.Valid {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "pinned requires author_id"})
+				return
+			}
+			params.ExcludePinned = true
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid pinned"})
 			return
 		}
 
-		// Sort chirps by created_at
-		sort.Slice(chirps, func(i, j int) bool {
-			if sortOrder == "desc" {
-				return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
+		var pinned []database.Chirp
+		var err error
+		if params.ExcludePinned && !params.CursorCreatedAt.Valid {
+			pinned, err = DB.ListPinnedChirps(r.Context(), database.ListPinnedChirpsParams{
+				AuthorID: params.AuthorID.UUID,
+				ViewerID: viewerID,
+			})
+			if err != nil {
+				logError(r, "failed to fetch chirps", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+				return
 			}
-			return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-		})
-
-		resp := make([]ChirpResponse, len(chirps))
-		for i, c := range chirps {
-			resp[i] = ChirpResponse{
-				ID:        c.ID.String(),
-				Body:      c.Body,
-				UserID:    c.UserID.String(),
-				CreatedAt: c.CreatedAt,
-				UpdatedAt: c.UpdatedAt,
+		}
+
+		writeFeed(w, r, DB, params, pinned)
+	}
+}
+
+// parseFeedPage reads the sort, limit and cursor query parameters shared by
+// chirp feeds into params. It writes the error response and returns false
+// otherwise.
+func parseFeedPage(w http.ResponseWriter, r *http.Request, params *database.ListFeedChirpsParams) bool {
+	params.SortDesc = r.URL.Query().Get("sort") == "desc"
+
+	// Without a limit every chirp is returned, as before pagination existed
+	if r.URL.Query().Get("limit") != "" {
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return false
+		}
+		params.RowLimit = sql.NullInt32{Int32: limit, Valid: true}
+	}
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+			return false
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	return true
+}
+
+// writeFeed runs the feed query and writes its chirps after any leading
+// ones, setting X-Next-Cursor when another page may follow
+func writeFeed(w http.ResponseWriter, r *http.Request, q *database.Queries, params database.ListFeedChirpsParams, leading []database.Chirp) {
+	chirps, err := q.ListFeedChirps(r.Context(), params)
+	if err != nil {
+		logError(r, "failed to fetch chirps", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+		return
+	}
+
+	resp := make([]ChirpResponse, 0, len(leading)+len(chirps))
+	for _, c := range leading {
+		resp = append(resp, chirpToResponse(c))
+	}
+	for _, c := range chirps {
+		resp = append(resp, chirpToResponse(c))
+	}
+	if err := attachAuthorHandles(r.Context(), q, resp); err != nil {
+		logError(r, "failed to fetch chirps", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+		return
+	}
+	if err := attachChirpMedia(r.Context(), q, resp); err != nil {
+		logError(r, "failed to fetch chirps", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+		return
+	}
+	if err := attachChirpPolls(r.Context(), q, resp, params.ViewerID); err != nil {
+		logError(r, "failed to fetch chirps", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+		return
+	}
+
+	if params.RowLimit.Valid && len(chirps) == int(params.RowLimit.Int32) {
+		last := chirps[len(chirps)-1]
+		w.Header().Set("X-Next-Cursor", encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// chirpToResponse converts a database chirp into its JSON representation
+func chirpToResponse(c database.Chirp) ChirpResponse {
+	resp := ChirpResponse{
+		ID:         c.ID.String(),
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+		Body:       c.Body,
+		UserID:     c.UserID.String(),
+		Visibility: c.Visibility,
+	}
+	if c.ReplyToID.Valid {
+		resp.ReplyToID = c.ReplyToID.UUID.String()
+	}
+	if c.Status != "" && c.Status != chirpStatusPublished {
+		resp.Status = c.Status
+	}
+	if c.ScheduledAt.Valid && c.Status == chirpStatusScheduled {
+		resp.ScheduledAt = &c.ScheduledAt.Time
+	}
+	if c.PublishError.Valid && c.Status != chirpStatusPublished {
+		resp.PublishError = c.PublishError.String
+	}
+	if c.PinnedAt.Valid {
+		resp.PinnedAt = &c.PinnedAt.Time
+	}
+	return resp
+}
+
+// censorChirp replaces bad words in a chirp body
+func censorChirp(body string) string {
+	words := strings.Fields(body)
+	for i, w := range words {
+		lower := strings.ToLower(w)
+		for _, bad := range badWords {
+			if lower == bad {
+				words[i] = "****"
 			}
 		}
+	}
+	return strings.Join(words, " ")
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
+// validateSchedule checks a requested status and scheduled_at pair and
+// returns the value to store, or an error message
+func validateSchedule(status string, scheduledAt *time.Time) (sql.NullTime, string) {
+	switch status {
+	case chirpStatusPublished, chirpStatusDraft:
+		if scheduledAt != nil {
+			return sql.NullTime{}, "scheduled_at requires status scheduled"
+		}
+		return sql.NullTime{}, ""
+	case chirpStatusScheduled:
+		if scheduledAt == nil {
+			return sql.NullTime{}, "scheduled_at is required"
+		}
+		if !scheduledAt.After(time.Now()) {
+			return sql.NullTime{}, "scheduled_at must be in the future"
+		}
+		return sql.NullTime{Time: scheduledAt.UTC(), Valid: true}, ""
+	default:
+		return sql.NullTime{}, "Invalid status"
+	}
+}
+
+// publishChirp records the notifications and chirp.created event for a
+// chirp that just became published and returns its response. parentAuthorID
+// is the author of the chirp being replied to, or uuid.Nil.
+func publishChirp(ctx context.Context, q *database.Queries, chirp database.Chirp, parentAuthorID uuid.UUID) (ChirpResponse, error) {
+	if err := notifications.ForChirp(ctx, q, chirp, parentAuthorID); err != nil {
+		return ChirpResponse{}, err
+	}
+
+	resps := []ChirpResponse{chirpToResponse(chirp)}
+	if err := attachAuthorHandles(ctx, q, resps); err != nil {
+		return ChirpResponse{}, err
 	}
+	if err := attachChirpMedia(ctx, q, resps); err != nil {
+		return ChirpResponse{}, err
+	}
+	// Tallies are shown as the author sees them
+	if err := attachChirpPolls(ctx, q, resps, uuid.NullUUID{UUID: chirp.UserID, Valid: true}); err != nil {
+		return ChirpResponse{}, err
+	}
+	return resps[0], outbox.Enqueue(ctx, q, outbox.TopicChirpCreated, chirp.ID, resps[0])
+}
+
+// PublishScheduledChirp runs the publish side effects for a chirp published
+// from a draft or by the scheduler. It matches scheduler.PublishFunc.
+func PublishScheduledChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	var parentAuthorID uuid.UUID
+	if chirp.ReplyToID.Valid {
+		parent, err := q.GetChirpByID(ctx, chirp.ReplyToID.UUID)
+		switch {
+		case err == nil:
+			parentAuthorID = parent.UserID
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+	}
+
+	_, err := publishChirp(ctx, q, chirp, parentAuthorID)
+	return err
 }
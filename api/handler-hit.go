@@ -3,18 +3,25 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"sync/atomic"
+
+	"github.com/xaitan80/go-server/internal/metrics"
 )
 
-// HitsHandler returns an HTML page showing the current hits
-func HitsHandler(cfg *atomic.Int32) http.HandlerFunc {
+// HitsHandler returns an HTML page showing the current hits, read from the
+// same registry that backs /metrics
+func HitsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
 
-		hits := cfg.Load() // safe read of counter
+		hits, err := metrics.Value("chirpy_fileserver_hits_total")
+		if err != nil {
+			logError(r, "failed to gather metrics", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
@@ -25,7 +32,7 @@ func HitsHandler(cfg *atomic.Int32) http.HandlerFunc {
     <p>Chirpy has been visited %d times!</p>
   </body>
 </html>
-`, hits)
+`, int64(hits))
 
 		fmt.Fprint(w, html)
 	}
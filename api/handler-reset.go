@@ -23,6 +23,7 @@ func ResetHandler(queries *database.Queries, platform string) http.HandlerFunc {
 		}
 
 		if err := queries.DeleteAllUsers(r.Context()); err != nil {
+			logError(r, "failed to delete users", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete users"})
 			return
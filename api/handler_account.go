@@ -0,0 +1,188 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/accounts"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// A finished export can be downloaded for this long
+const exportTTL = 24 * time.Hour
+
+type deleteAccountResponse struct {
+	DeletionRequestedAt time.Time `json:"deletion_requested_at"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type exportResponse struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeleteAccountHandler handles DELETE /api/users/me
+// The password must be confirmed. The account is hard-deleted once the grace
+// period has passed; logging in before then cancels the deletion.
+func DeleteAccountHandler(db *sql.DB, queries *database.Queries, jwtSecret string, grace time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+
+		user, err := queries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+			return
+		}
+		if err := auth.CheckPasswordHash(req.Password, user.HashedPassword.String); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid password"})
+			return
+		}
+
+		// Sign out every session along with the deletion request
+		if !user.DeletionRequestedAt.Valid {
+			err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+				user, err = q.RequestUserDeletion(r.Context(), userID)
+				if err != nil {
+					return err
+				}
+				return q.RevokeUserRefreshTokens(r.Context(), userID)
+			})
+			if err != nil {
+				logError(r, "failed to delete account", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete account"})
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(deleteAccountResponse{
+			DeletionRequestedAt: user.DeletionRequestedAt.Time,
+			DeletionScheduledAt: user.DeletionRequestedAt.Time.Add(grace),
+		})
+	}
+}
+
+// StartExportHandler handles POST /api/users/me/export
+// Queues a ZIP archive of the caller's data for the export worker and
+// returns 202. If an export is already being built, that one is returned.
+func StartExportHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		export, err := queries.CreateDataExport(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Already pending
+			export, err = queries.GetLatestDataExport(r.Context(), userID)
+		}
+		if err != nil {
+			logError(r, "failed to start export", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to start export"})
+			return
+		}
+
+		writeExportStatus(w, http.StatusAccepted, export)
+	}
+}
+
+// ExportAccountHandler handles GET /api/users/me/export
+// Downloads the caller's latest export once it is ready. While it is being
+// built the response is 202 with its status; a failed export is reported
+// with status "failed". Without a recent export it returns 404.
+func ExportAccountHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		export, err := queries.GetLatestDataExport(r.Context(), userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logError(r, "failed to fetch export", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch export"})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) || time.Since(export.CreatedAt) >= exportTTL {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "No export found; POST to start one"})
+			return
+		}
+
+		switch export.Status {
+		case accounts.ExportReady:
+			filename := fmt.Sprintf("chirpy-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.Write(export.Archive)
+		case accounts.ExportPending:
+			writeExportStatus(w, http.StatusAccepted, export)
+		default:
+			writeExportStatus(w, http.StatusOK, export)
+		}
+	}
+}
+
+// writeExportStatus responds with an export's status. Pending exports ask
+// the client to check back shortly.
+func writeExportStatus(w http.ResponseWriter, code int, export database.DataExport) {
+	w.Header().Set("Content-Type", "application/json")
+	if export.Status == accounts.ExportPending {
+		w.Header().Set("Retry-After", "5")
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(exportResponse{
+		ID:        export.ID.String(),
+		Status:    export.Status,
+		CreatedAt: export.CreatedAt,
+	})
+}
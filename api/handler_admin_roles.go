@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/authz"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// errLastAdmin aborts a role change that would leave no admins
+var errLastAdmin = errors.New("last admin")
+
+type roleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRolesHandler handles GET /admin/roles
+func ListRolesHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		roles, err := queries.ListRoles(r.Context())
+		if err != nil {
+			logError(r, "failed to fetch roles", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch roles"})
+			return
+		}
+
+		resp := make([]roleResponse, 0, len(roles))
+		for _, role := range roles {
+			perms, err := queries.ListRolePermissions(r.Context(), role.Name)
+			if err != nil {
+				logError(r, "failed to fetch roles", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch roles"})
+				return
+			}
+			if perms == nil {
+				perms = []string{}
+			}
+			resp = append(resp, roleResponse{
+				Name:        role.Name,
+				Description: role.Description,
+				Permissions: perms,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// SetUserRoleHandler handles PUT /admin/users/{id}/role
+func SetUserRoleHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		// Expected path: /admin/users/{id}/role
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		targetID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		if !authz.IsValidRole(req.Role) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown role"})
+			return
+		}
+
+		var user database.User
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			current, err := q.GetUserByID(r.Context(), targetID)
+			if err != nil {
+				return err
+			}
+
+			// Never leave the instance without an admin
+			if current.Role == authz.RoleAdmin && req.Role != authz.RoleAdmin {
+				admins, err := q.CountUsersWithRole(r.Context(), authz.RoleAdmin)
+				if err != nil {
+					return err
+				}
+				if admins <= 1 {
+					return errLastAdmin
+				}
+			}
+
+			user, err = q.SetUserRole(r.Context(), database.SetUserRoleParams{
+				ID:   targetID,
+				Role: req.Role,
+			})
+			return err
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+			return
+		case errors.Is(err, errLastAdmin):
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Cannot remove the last admin"})
+			return
+		case err != nil:
+			logError(r, "failed to update role", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update role"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		}{ID: user.ID.String(), Role: user.Role})
+	}
+}
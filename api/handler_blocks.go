@@ -0,0 +1,183 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// BlockUserHandler handles POST and DELETE /api/users/{id}/block
+// Blocked users can't see each other's chirps, reply to or mention each
+// other, or share conversations. Blocking also ends any follows between them.
+func BlockUserHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, targetID, ok := relationshipTarget(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+				if err := q.BlockUser(r.Context(), database.BlockUserParams{
+					BlockerID: userID,
+					BlockedID: targetID,
+				}); err != nil {
+					return err
+				}
+				return q.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+					UserID:  userID,
+					OtherID: targetID,
+				})
+			})
+		} else {
+			err = queries.UnblockUser(r.Context(), database.UnblockUserParams{
+				BlockerID: userID,
+				BlockedID: targetID,
+			})
+		}
+		if err != nil {
+			logError(r, "failed to update block", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update block"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MuteUserHandler handles POST and DELETE /api/users/{id}/mute
+// Muted authors are hidden from the muter's feeds only.
+func MuteUserHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, targetID, ok := relationshipTarget(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			err = queries.MuteUser(r.Context(), database.MuteUserParams{
+				MuterID: userID,
+				MutedID: targetID,
+			})
+		} else {
+			err = queries.UnmuteUser(r.Context(), database.UnmuteUserParams{
+				MuterID: userID,
+				MutedID: targetID,
+			})
+		}
+		if err != nil {
+			logError(r, "failed to update mute", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update mute"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// FollowUserHandler handles POST and DELETE /api/users/{id}/follow
+// Followers can see the user's followers-only chirps.
+func FollowUserHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, targetID, ok := relationshipTarget(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			blocked, blockErr := queries.HasBlockBetweenAny(r.Context(), database.HasBlockBetweenAnyParams{
+				UserID:   userID,
+				OtherIds: []uuid.UUID{targetID},
+			})
+			if blockErr != nil {
+				logError(r, "failed to update follow", blockErr)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update follow"})
+				return
+			}
+			if blocked {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot follow this user"})
+				return
+			}
+			err = queries.FollowUser(r.Context(), database.FollowUserParams{
+				FollowerID: userID,
+				FolloweeID: targetID,
+			})
+		} else {
+			err = queries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+				FollowerID: userID,
+				FolloweeID: targetID,
+			})
+		}
+		if err != nil {
+			logError(r, "failed to update follow", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update follow"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// relationshipTarget validates a POST/DELETE /api/users/{id}/... request and
+// returns the caller and the target user. It writes the error response and
+// returns false otherwise.
+func relationshipTarget(w http.ResponseWriter, r *http.Request, queries *database.Queries, jwtSecret string) (uuid.UUID, uuid.UUID, bool) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// Expected path: /api/users/{id}/block, /mute or /follow
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	targetID, err := uuid.Parse(parts[2])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	if targetID == userID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot do this to yourself"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := queries.GetUserByID(r.Context(), targetID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+		} else {
+			logError(r, "failed to fetch user", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch user"})
+		}
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, targetID, true
+}
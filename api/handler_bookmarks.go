@@ -0,0 +1,262 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Most chirps a user can pin to their profile
+const maxPinnedChirps = 3
+
+// BookmarkChirpHandler handles POST and DELETE /api/chirps/{id}/bookmark
+// Bookmarks are private to the user who saved them.
+func BookmarkChirpHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, chirpID, ok := chirpActionTarget(w, r, jwtSecret)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			err := queries.RemoveBookmark(r.Context(), database.RemoveBookmarkParams{
+				UserID:  userID,
+				ChirpID: chirpID,
+			})
+			if err != nil {
+				logError(r, "failed to update bookmark", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update bookmark"})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		chirp, err := visibleChirp(r.Context(), queries, chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			return
+		}
+
+		err = queries.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+			UserID:  userID,
+			ChirpID: chirp.ID,
+		})
+		if err != nil {
+			logError(r, "failed to update bookmark", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update bookmark"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListBookmarksHandler handles GET /api/bookmarks
+// Returns the caller's bookmarked chirps, most recently saved first, with
+// cursor/limit pagination; the next cursor is in the X-Next-Cursor header.
+func ListBookmarksHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListBookmarkedChirpsParams{
+			UserID:   userID,
+			RowLimit: limit,
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+			params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListBookmarkedChirps(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch bookmarks", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch bookmarks"})
+			return
+		}
+
+		resp := make([]ChirpResponse, len(rows))
+		for i, row := range rows {
+			resp[i] = chirpToResponse(database.Chirp{
+				ID:          row.ID,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+				Body:        row.Body,
+				UserID:      row.UserID,
+				AuthorID:    row.AuthorID,
+				ReplyToID:   row.ReplyToID,
+				Status:      row.Status,
+				ScheduledAt: row.ScheduledAt,
+				PinnedAt:    row.PinnedAt,
+				Visibility:  row.Visibility,
+			})
+		}
+		viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+		if err := attachAuthorHandles(r.Context(), queries, resp); err != nil {
+			logError(r, "failed to fetch bookmarks", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch bookmarks"})
+			return
+		}
+		if err := attachChirpMedia(r.Context(), queries, resp); err != nil {
+			logError(r, "failed to fetch bookmarks", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch bookmarks"})
+			return
+		}
+		if err := attachChirpPolls(r.Context(), queries, resp, viewerID); err != nil {
+			logError(r, "failed to fetch bookmarks", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch bookmarks"})
+			return
+		}
+
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			w.Header().Set("X-Next-Cursor", encodeCursor(last.BookmarkedAt, last.ID))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// PinChirpHandler handles POST and DELETE /api/chirps/{id}/pin
+// Users can pin up to maxPinnedChirps of their own published chirps;
+// pinning a chirp that is already pinned changes nothing.
+func PinChirpHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, chirpID, ok := chirpActionTarget(w, r, jwtSecret)
+		if !ok {
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			n, err := queries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+				ID:     chirpID,
+				UserID: userID,
+			})
+			if err != nil {
+				logError(r, "failed to unpin chirp", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to unpin chirp"})
+				return
+			}
+			if n == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Pinned chirp not found"})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		chirp, err := queries.GetChirpByID(r.Context(), chirpID)
+		if err != nil || chirp.HiddenAt.Valid {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			return
+		}
+		if chirp.UserID != userID {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You can only pin your own chirps"})
+			return
+		}
+
+		// The update only matches while the user is under the pin limit, or
+		// if the chirp is already pinned
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			if err := q.LockUserPins(r.Context(), userID); err != nil {
+				return err
+			}
+			chirp, err = q.PinChirp(r.Context(), database.PinChirpParams{
+				ID:        chirpID,
+				UserID:    userID,
+				MaxPinned: maxPinnedChirps,
+			})
+			return err
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("You can pin at most %d chirps", maxPinnedChirps)})
+			return
+		}
+		if err != nil {
+			logError(r, "failed to pin chirp", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to pin chirp"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chirpToResponse(chirp))
+	}
+}
+
+// chirpActionTarget authenticates a POST/DELETE /api/chirps/{id}/...
+// request and returns the caller and chirp ID. It writes the error response
+// and returns false otherwise.
+func chirpActionTarget(w http.ResponseWriter, r *http.Request, jwtSecret string) (uuid.UUID, uuid.UUID, bool) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// Expected path: /api/chirps/{id}/{action}
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	chirpID, err := uuid.Parse(parts[2])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid chirp ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, chirpID, true
+}
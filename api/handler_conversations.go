@@ -0,0 +1,585 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+const (
+	// Conversations are one-to-one or small groups, including the creator
+	maxConversationMembers = 8
+	maxMessageLength       = 1000
+)
+
+// Request struct for starting a conversation
+type startConversationRequest struct {
+	MemberIDs []string `json:"member_ids"`
+	Body      string   `json:"body,omitempty"`
+}
+
+// Request struct for sending a message
+type sendMessageRequest struct {
+	Body string `json:"body"`
+}
+
+// Response struct for a conversation member; last_read_at is the read receipt
+type conversationMemberResponse struct {
+	UserID     string     `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+// Response struct for a conversation
+type conversationResponse struct {
+	ID          string                       `json:"id"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+	CreatedBy   string                       `json:"created_by"`
+	UnreadCount *int64                       `json:"unread_count,omitempty"`
+	Members     []conversationMemberResponse `json:"members,omitempty"`
+}
+
+// Response struct for a message
+type messageResponse struct {
+	ID             string    `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID string    `json:"conversation_id"`
+	SenderID       string    `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+// StartConversationHandler handles POST /api/conversations
+// Starting a one-to-one conversation that already exists reuses it.
+func StartConversationHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		var req startConversationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+
+		if len(req.Body) > maxMessageLength {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Message is too long"})
+			return
+		}
+
+		// Collect distinct members other than the caller
+		seen := map[uuid.UUID]bool{userID: true}
+		var memberIDs []uuid.UUID
+		for _, idStr := range req.MemberIDs {
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid member ID"})
+				return
+			}
+			if !seen[id] {
+				seen[id] = true
+				memberIDs = append(memberIDs, id)
+			}
+		}
+		if len(memberIDs) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "At least one other member is required"})
+			return
+		}
+		if len(memberIDs)+1 > maxConversationMembers {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Too many members"})
+			return
+		}
+
+		count, err := queries.CountUsersByIDs(r.Context(), memberIDs)
+		if err != nil {
+			logError(r, "failed to fetch users", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch users"})
+			return
+		}
+		if count != int64(len(memberIDs)) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+			return
+		}
+
+		blocked, err := queries.HasBlockBetweenAny(r.Context(), database.HasBlockBetweenAnyParams{
+			UserID:   userID,
+			OtherIds: memberIDs,
+		})
+		if err != nil {
+			logError(r, "failed to fetch users", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch users"})
+			return
+		}
+		if blocked {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot message a blocked user"})
+			return
+		}
+
+		status := http.StatusCreated
+		var conversation database.Conversation
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			if len(memberIDs) == 1 {
+				existing, err := q.FindDirectConversation(r.Context(), database.FindDirectConversationParams{
+					UserA: userID,
+					UserB: memberIDs[0],
+				})
+				if err == nil {
+					conversation = existing
+					status = http.StatusOK
+				} else if !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+			}
+
+			if status == http.StatusCreated {
+				conversation, err = q.CreateConversation(r.Context(), userID)
+				if err != nil {
+					return err
+				}
+				for _, id := range append([]uuid.UUID{userID}, memberIDs...) {
+					if err := q.AddConversationMember(r.Context(), database.AddConversationMemberParams{
+						ConversationID: conversation.ID,
+						UserID:         id,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+
+			if req.Body == "" {
+				return nil
+			}
+			_, err := sendMessage(r, q, conversation.ID, userID, req.Body)
+			return err
+		})
+		if err != nil {
+			logError(r, "failed to start conversation", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to start conversation"})
+			return
+		}
+
+		resp, err := conversationWithMembers(r, queries, conversation)
+		if err != nil {
+			logError(r, "failed to fetch conversation members", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch conversation members"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ListConversationsHandler handles GET /api/conversations
+// Conversations are ordered by most recent activity and paginated with
+// cursor and limit.
+func ListConversationsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListConversationsForUserParams{
+			UserID:   userID,
+			RowLimit: limit,
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			updatedAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.BeforeUpdatedAt = sql.NullTime{Time: updatedAt, Valid: true}
+			params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListConversationsForUser(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch conversations", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch conversations"})
+			return
+		}
+
+		resp := struct {
+			Conversations []conversationResponse `json:"conversations"`
+			NextCursor    string                 `json:"next_cursor,omitempty"`
+		}{Conversations: make([]conversationResponse, len(rows))}
+		for i, c := range rows {
+			unread := c.UnreadCount
+			resp.Conversations[i] = conversationResponse{
+				ID:          c.ID.String(),
+				CreatedAt:   c.CreatedAt,
+				UpdatedAt:   c.UpdatedAt,
+				CreatedBy:   c.CreatedBy.String(),
+				UnreadCount: &unread,
+			}
+		}
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			resp.NextCursor = encodeCursor(last.UpdatedAt, last.ID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// GetConversationHandler handles GET /api/conversations/{id}
+func GetConversationHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		conversation, _, ok := conversationForCaller(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		resp, err := conversationWithMembers(r, queries, conversation)
+		if err != nil {
+			logError(r, "failed to fetch conversation members", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch conversation members"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ListMessagesHandler handles GET /api/conversations/{id}/messages
+// Messages are returned newest first and paginated with cursor and limit.
+func ListMessagesHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		conversation, _, ok := conversationForCaller(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListMessagesParams{
+			ConversationID: conversation.ID,
+			RowLimit:       limit,
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+			params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListMessages(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch messages", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch messages"})
+			return
+		}
+
+		resp := struct {
+			Messages   []messageResponse `json:"messages"`
+			NextCursor string            `json:"next_cursor,omitempty"`
+		}{Messages: make([]messageResponse, len(rows))}
+		for i, m := range rows {
+			resp.Messages[i] = messageToResponse(m)
+		}
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// SendMessageHandler handles POST /api/conversations/{id}/messages
+func SendMessageHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		conversation, userID, ok := conversationForCaller(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var req sendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		if req.Body == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Message body is required"})
+			return
+		}
+		if len(req.Body) > maxMessageLength {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Message is too long"})
+			return
+		}
+
+		blocked, err := queries.HasBlockInConversation(r.Context(), database.HasBlockInConversationParams{
+			UserID:         userID,
+			ConversationID: conversation.ID,
+		})
+		if err != nil {
+			logError(r, "failed to send message", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to send message"})
+			return
+		}
+		if blocked {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot message a blocked user"})
+			return
+		}
+
+		var message database.Message
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			var err error
+			message, err = sendMessage(r, q, conversation.ID, userID, req.Body)
+			return err
+		})
+		if err != nil {
+			logError(r, "failed to send message", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to send message"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(messageToResponse(message))
+	}
+}
+
+// MarkConversationReadHandler handles POST /api/conversations/{id}/read
+func MarkConversationReadHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		conversation, userID, ok := conversationForCaller(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		if err := queries.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		}); err != nil {
+			logError(r, "failed to mark conversation read", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to mark conversation read"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// LeaveConversationHandler handles POST /api/conversations/{id}/leave
+func LeaveConversationHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		conversation, userID, ok := conversationForCaller(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		if _, err := queries.LeaveConversation(r.Context(), database.LeaveConversationParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		}); err != nil {
+			logError(r, "failed to leave conversation", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to leave conversation"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// conversationForCaller authenticates the request and loads the conversation
+// in /api/conversations/{id}/... if the caller is an active member. It writes
+// the error response and returns false otherwise.
+func conversationForCaller(w http.ResponseWriter, r *http.Request, queries *database.Queries, jwtSecret string) (database.Conversation, uuid.UUID, bool) {
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 3 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+		return database.Conversation{}, uuid.Nil, false
+	}
+	conversationID, err := uuid.Parse(parts[2])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid conversation ID"})
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	// Non-members get 404 so conversation IDs can't be probed
+	conversation, err := queries.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Conversation not found"})
+		} else {
+			logError(r, "failed to fetch conversation", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch conversation"})
+		}
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	return conversation, userID, true
+}
+
+// sendMessage stores a message, bumps the conversation and marks it read for
+// the sender. Call it inside a transaction.
+func sendMessage(r *http.Request, q *database.Queries, conversationID, senderID uuid.UUID, body string) (database.Message, error) {
+	message, err := q.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Body:           body,
+	})
+	if err != nil {
+		return database.Message{}, err
+	}
+	if err := q.TouchConversation(r.Context(), conversationID); err != nil {
+		return database.Message{}, err
+	}
+	err = q.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         senderID,
+	})
+	return message, err
+}
+
+// conversationWithMembers builds a conversation response including members
+// and their read receipts
+func conversationWithMembers(r *http.Request, queries *database.Queries, c database.Conversation) (conversationResponse, error) {
+	members, err := queries.ListConversationMembers(r.Context(), c.ID)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	resp := conversationResponse{
+		ID:        c.ID.String(),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		CreatedBy: c.CreatedBy.String(),
+		Members:   make([]conversationMemberResponse, len(members)),
+	}
+	for i, m := range members {
+		resp.Members[i] = conversationMemberResponse{
+			UserID:   m.UserID.String(),
+			JoinedAt: m.JoinedAt,
+		}
+		if m.LastReadAt.Valid {
+			resp.Members[i].LastReadAt = &m.LastReadAt.Time
+		}
+	}
+	return resp, nil
+}
+
+// messageToResponse converts a database message into its JSON representation
+func messageToResponse(m database.Message) messageResponse {
+	return messageResponse{
+		ID:             m.ID.String(),
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID.String(),
+		SenderID:       m.SenderID.String(),
+		Body:           m.Body,
+	}
+}
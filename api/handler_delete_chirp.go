@@ -10,10 +10,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/xaitan80/go-server/internal/auth"
 	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/outbox"
 )
 
 // DeleteChirpHandler handles DELETE /api/chirps/{id}
-func DeleteChirpHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+func DeleteChirpHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract chirp ID from URL
 		parts := strings.Split(r.URL.Path, "/")
@@ -45,6 +46,7 @@ func DeleteChirpHandler(queries *database.Queries, jwtSecret string) http.Handle
 				w.WriteHeader(http.StatusNotFound)
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
 			} else {
+				logError(r, "failed to fetch chirp", err)
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirp"})
 			}
@@ -58,8 +60,19 @@ func DeleteChirpHandler(queries *database.Queries, jwtSecret string) http.Handle
 			return
 		}
 
-		// Delete the chirp
-		if err := queries.DeleteChirp(r.Context(), chirpID); err != nil {
+		// Soft-delete the chirp and record the event atomically; the author
+		// can restore it within chirpRestoreWindow
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			if err := q.DeleteChirp(r.Context(), database.DeleteChirpParams{
+				ID:        chirpID,
+				DeletedBy: uuid.NullUUID{UUID: userID, Valid: true},
+			}); err != nil {
+				return err
+			}
+			return outbox.Enqueue(r.Context(), q, outbox.TopicChirpDeleted, chirp.ID, chirpToResponse(chirp))
+		})
+		if err != nil {
+			logError(r, "failed to delete chirp", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete chirp"})
 			return
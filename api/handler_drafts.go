@@ -0,0 +1,304 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+)
+
+// ListDraftsHandler handles GET /api/drafts
+// Lists the caller's drafts and scheduled chirps, soonest first; status
+// filters to one of them.
+func ListDraftsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		var status sql.NullString
+		switch s := r.URL.Query().Get("status"); s {
+		case "":
+		case chirpStatusDraft, chirpStatusScheduled:
+			status = sql.NullString{String: s, Valid: true}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid status"})
+			return
+		}
+
+		chirps, err := queries.ListUnpublishedChirps(r.Context(), database.ListUnpublishedChirpsParams{
+			UserID: userID,
+			Status: status,
+		})
+		if err != nil {
+			logError(r, "failed to fetch drafts", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch drafts"})
+			return
+		}
+
+		resp := make([]ChirpResponse, len(chirps))
+		for i, c := range chirps {
+			resp[i] = chirpToResponse(c)
+		}
+		if err := attachChirpMedia(r.Context(), queries, resp); err != nil {
+			logError(r, "failed to fetch drafts", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch drafts"})
+			return
+		}
+		if err := attachChirpPolls(r.Context(), queries, resp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			logError(r, "failed to fetch drafts", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch drafts"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// UpdateDraftHandler handles PUT /api/drafts/{id}
+// Any of body, status and scheduled_at can be changed. Setting status to
+// "published" publishes the chirp immediately, "draft" unschedules it.
+// Bodies longer than maxLength are rejected.
+func UpdateDraftHandler(db *sql.DB, queries *database.Queries, jwtSecret string, maxLength int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, draftID, ok := draftTarget(w, r, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Body        *string    `json:"body"`
+			Status      *string    `json:"status"`
+			ScheduledAt *time.Time `json:"scheduled_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+
+		draft, err := queries.GetUnpublishedChirp(r.Context(), database.GetUnpublishedChirpParams{
+			ID:     draftID,
+			UserID: userID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Draft not found"})
+			} else {
+				logError(r, "failed to fetch draft", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch draft"})
+			}
+			return
+		}
+
+		body := draft.Body
+		if req.Body != nil {
+			if len(*req.Body) > maxLength {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp is too long"})
+				return
+			}
+			body = censorChirp(*req.Body)
+		}
+
+		status, scheduledAt, errMsg := draftTransition(draft, req.Status, req.ScheduledAt)
+		if errMsg != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: errMsg})
+			return
+		}
+
+		// A poll's closing time was checked against the publish time known
+		// when it was created, so check it again when that time changes
+		if (req.Status != nil || req.ScheduledAt != nil) && status != chirpStatusDraft {
+			publishAt := time.Now()
+			if status == chirpStatusScheduled {
+				publishAt = scheduledAt.Time
+			}
+			poll, err := queries.GetPollByChirpID(r.Context(), draftID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				logError(r, "failed to fetch poll", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch poll"})
+				return
+			}
+			if err == nil {
+				if errMsg := validatePollWindow(poll.ClosesAt, publishAt); errMsg != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: errMsg})
+					return
+				}
+			}
+		}
+
+		if status == chirpStatusPublished {
+			user, err := queries.GetUserByID(r.Context(), userID)
+			if err != nil {
+				logError(r, "failed to fetch user", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch user"})
+				return
+			}
+			if isSuspended(user) {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Account suspended until " + user.SuspendedUntil.Time.Format(time.RFC3339)})
+				return
+			}
+		}
+
+		var chirp database.Chirp
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			stored := status
+			if status == chirpStatusPublished {
+				stored = chirpStatusDraft
+			}
+			chirp, err = q.UpdateUnpublishedChirp(r.Context(), database.UpdateUnpublishedChirpParams{
+				ID:          draftID,
+				UserID:      userID,
+				Body:        body,
+				Status:      stored,
+				ScheduledAt: scheduledAt,
+			})
+			if err != nil || status != chirpStatusPublished {
+				return err
+			}
+
+			chirp, err = q.PublishChirp(r.Context(), draftID)
+			if err != nil {
+				return err
+			}
+			return PublishScheduledChirp(r.Context(), q, chirp)
+		})
+		if err != nil {
+			// The scheduler may have published it in the meantime
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Draft not found"})
+			} else {
+				logError(r, "failed to update draft", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update draft"})
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chirpToResponse(chirp))
+	}
+}
+
+// DeleteDraftHandler handles DELETE /api/drafts/{id}
+// Discards a draft or cancels a scheduled chirp, along with its media.
+func DeleteDraftHandler(queries *database.Queries, store media.BlobStore, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, draftID, ok := draftTarget(w, r, jwtSecret)
+		if !ok {
+			return
+		}
+
+		blobKeys, err := queries.DeleteUnpublishedChirp(r.Context(), database.DeleteUnpublishedChirpParams{
+			ID:     draftID,
+			UserID: userID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Draft not found"})
+			} else {
+				logError(r, "failed to delete draft", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete draft"})
+			}
+			return
+		}
+		if err := media.DeleteAll(r.Context(), store, blobKeys); err != nil {
+			// The draft is gone either way; the blobs are only wasted space
+			logError(r, "failed to delete draft media", err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// draftTransition returns the status and schedule a draft moves to when an
+// update sets status and/or scheduled_at (nil when not given), or an error
+// message for an invalid change
+func draftTransition(draft database.Chirp, status *string, scheduledAt *time.Time) (string, sql.NullTime, string) {
+	if status == nil && scheduledAt == nil {
+		return draft.Status, draft.ScheduledAt, ""
+	}
+
+	next := draft.Status
+	if status != nil {
+		next = *status
+	}
+	// Keep the existing time when only the body changes on a scheduled chirp
+	requested := scheduledAt
+	if requested == nil && next == chirpStatusScheduled && draft.ScheduledAt.Valid {
+		requested = &draft.ScheduledAt.Time
+	}
+	nextScheduledAt, errMsg := validateSchedule(next, requested)
+	return next, nextScheduledAt, errMsg
+}
+
+// draftTarget authenticates a /api/drafts/{id} request and returns the
+// caller and draft ID. It writes the error response and returns false
+// otherwise.
+func draftTarget(w http.ResponseWriter, r *http.Request, jwtSecret string) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// Expected path: /api/drafts/{id}
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 3 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	draftID, err := uuid.Parse(parts[2])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid draft ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, draftID, true
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		status      string
+		scheduledAt *time.Time
+		wantValid   bool
+		wantErr     string
+	}{
+		{"draft", chirpStatusDraft, nil, false, ""},
+		{"published", chirpStatusPublished, nil, false, ""},
+		{"scheduled", chirpStatusScheduled, &future, true, ""},
+		{"draft with time", chirpStatusDraft, &future, false, "scheduled_at requires status scheduled"},
+		{"scheduled without time", chirpStatusScheduled, nil, false, "scheduled_at is required"},
+		{"scheduled in the past", chirpStatusScheduled, &past, false, "scheduled_at must be in the future"},
+		{"unknown status", "archived", nil, false, "Invalid status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errMsg := validateSchedule(tt.status, tt.scheduledAt)
+			if errMsg != tt.wantErr {
+				t.Fatalf("error = %q, want %q", errMsg, tt.wantErr)
+			}
+			if got.Valid != tt.wantValid {
+				t.Errorf("scheduled_at valid = %v, want %v", got.Valid, tt.wantValid)
+			}
+			if tt.wantValid && !got.Time.Equal(*tt.scheduledAt) {
+				t.Errorf("scheduled_at = %v, want %v", got.Time, *tt.scheduledAt)
+			}
+		})
+	}
+}
+
+func TestDraftTransition(t *testing.T) {
+	at := time.Now().Add(time.Hour).UTC()
+	later := at.Add(time.Hour)
+	draft := database.Chirp{Status: chirpStatusDraft}
+	scheduled := database.Chirp{Status: chirpStatusScheduled, ScheduledAt: sql.NullTime{Time: at, Valid: true}}
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		draft       database.Chirp
+		status      *string
+		scheduledAt *time.Time
+		wantStatus  string
+		wantAt      *time.Time
+		wantErr     string
+	}{
+		{"body only keeps draft", draft, nil, nil, chirpStatusDraft, nil, ""},
+		{"body only keeps schedule", scheduled, nil, nil, chirpStatusScheduled, &at, ""},
+		{"schedule a draft", draft, str(chirpStatusScheduled), &at, chirpStatusScheduled, &at, ""},
+		{"reschedule", scheduled, nil, &later, chirpStatusScheduled, &later, ""},
+		{"status keeps existing time", scheduled, str(chirpStatusScheduled), nil, chirpStatusScheduled, &at, ""},
+		{"unschedule", scheduled, str(chirpStatusDraft), nil, chirpStatusDraft, nil, ""},
+		{"publish a scheduled chirp", scheduled, str(chirpStatusPublished), nil, chirpStatusPublished, nil, ""},
+		{"schedule without time", draft, str(chirpStatusScheduled), nil, "", nil, "scheduled_at is required"},
+		{"time on a draft", draft, nil, &at, "", nil, "scheduled_at requires status scheduled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, scheduledAt, errMsg := draftTransition(tt.draft, tt.status, tt.scheduledAt)
+			if errMsg != tt.wantErr {
+				t.Fatalf("error = %q, want %q", errMsg, tt.wantErr)
+			}
+			if errMsg != "" {
+				return
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+			if scheduledAt.Valid != (tt.wantAt != nil) || (tt.wantAt != nil && !scheduledAt.Time.Equal(*tt.wantAt)) {
+				t.Errorf("scheduled_at = %v, want %v", scheduledAt, tt.wantAt)
+			}
+		})
+	}
+}
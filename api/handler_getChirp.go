@@ -1,7 +1,9 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"strings"
@@ -12,15 +14,20 @@ import (
 
 // Response struct for JSON output
 type chirpResponse struct {
-	ID        string `json:"id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Body      string `json:"body"`
-	UserID    string `json:"user_id"`
+	ID           string               `json:"id"`
+	CreatedAt    string               `json:"created_at"`
+	UpdatedAt    string               `json:"updated_at"`
+	Body         string               `json:"body"`
+	UserID       string               `json:"user_id"`
+	AuthorHandle string               `json:"author_handle,omitempty"`
+	ReplyToID    string               `json:"reply_to_id,omitempty"`
+	Visibility   string               `json:"visibility"`
+	Attachments  []AttachmentResponse `json:"attachments,omitempty"`
+	Poll         *PollResponse        `json:"poll,omitempty"`
 }
 
 // GetChirpHandler handles GET /api/chirps/{chirpID}
-func GetChirpHandler(queries *database.Queries) http.HandlerFunc {
+func GetChirpHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only allow GET
 		if r.Method != http.MethodGet {
@@ -29,6 +36,13 @@ func GetChirpHandler(queries *database.Queries) http.HandlerFunc {
 			return
 		}
 
+		viewerID, err := optionalViewer(r, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid token"})
+			return
+		}
+
 		// Extract chirpID from the path
 		// Expected path: /api/chirps/{chirpID}
 		parts := splitPath(r.URL.Path)
@@ -45,22 +59,59 @@ func GetChirpHandler(queries *database.Queries) http.HandlerFunc {
 			return
 		}
 
-		// Fetch chirp from database
-		chirp, err := queries.GetChirpByID(r.Context(), chirpID)
-		if err != nil {
+		// Chirps the viewer may not see are reported as missing
+		chirp, err := visibleChirp(r.Context(), queries, chirpID, viewerID)
+		if errors.Is(err, sql.ErrNoRows) {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
 			return
 		}
+		if err != nil {
+			logError(r, "failed to fetch chirp", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirp"})
+			return
+		}
 
 		// Build response with string UUIDs
 		resp := chirpResponse{
-			ID:        chirp.ID.String(),
-			CreatedAt: chirp.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt: chirp.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			Body:      chirp.Body,
-			UserID:    chirp.UserID.String(),
+			ID:         chirp.ID.String(),
+			CreatedAt:  chirp.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:  chirp.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Body:       chirp.Body,
+			UserID:     chirp.UserID.String(),
+			Visibility: chirp.Visibility,
+		}
+		if chirp.ReplyToID.Valid {
+			resp.ReplyToID = chirp.ReplyToID.UUID.String()
+		}
+
+		author, err := queries.GetUserByID(r.Context(), chirp.UserID)
+		if err != nil {
+			logError(r, "failed to fetch author", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch author"})
+			return
+		}
+		resp.AuthorHandle = author.Handle.String
+
+		attachments, err := attachmentsByChirp(r.Context(), queries, []uuid.UUID{chirp.ID})
+		if err != nil {
+			logError(r, "failed to fetch attachments", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch attachments"})
+			return
+		}
+		resp.Attachments = attachments[chirp.ID]
+
+		polls, err := pollsByChirp(r.Context(), queries, []uuid.UUID{chirp.ID}, viewerID)
+		if err != nil {
+			logError(r, "failed to fetch poll", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch poll"})
+			return
 		}
+		resp.Poll = polls[chirp.ID]
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
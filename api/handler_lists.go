@@ -0,0 +1,549 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// List limits
+const (
+	maxListNameLength        = 50
+	maxListDescriptionLength = 160
+	maxListMembers           = 500
+)
+
+type listRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
+type listResponse struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OwnerID     string    `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+	Subscribed  bool      `json:"subscribed,omitempty"`
+}
+
+type listMemberResponse struct {
+	UserID      string    `json:"user_id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+type addListMemberRequest struct {
+	UserID string `json:"user_id"`
+}
+
+func listToResponse(l database.List) listResponse {
+	return listResponse{
+		ID:          l.ID.String(),
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+		OwnerID:     l.OwnerID.String(),
+		Name:        l.Name,
+		Description: l.Description,
+		Private:     l.IsPrivate,
+	}
+}
+
+// CreateListHandler handles POST /api/lists
+func CreateListHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		req, ok := decodeListRequest(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := queries.CreateList(r.Context(), database.CreateListParams{
+			OwnerID:     userID,
+			Name:        req.Name,
+			Description: req.Description,
+			IsPrivate:   req.Private,
+		})
+		if err != nil {
+			logError(r, "failed to create list", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create list"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(listToResponse(list))
+	}
+}
+
+// ListListsHandler handles GET /api/lists
+// Returns the lists the caller owns or subscribes to.
+func ListListsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		rows, err := queries.ListUserLists(r.Context(), userID)
+		if err != nil {
+			logError(r, "failed to fetch lists", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch lists"})
+			return
+		}
+
+		resp := make([]listResponse, len(rows))
+		for i, row := range rows {
+			resp[i] = listToResponse(database.List{
+				ID:          row.ID,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+				OwnerID:     row.OwnerID,
+				Name:        row.Name,
+				Description: row.Description,
+				IsPrivate:   row.IsPrivate,
+			})
+			resp[i].Subscribed = row.Subscribed
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// GetListHandler handles GET /api/lists/{id}
+func GetListHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, _, ok := listForViewer(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listToResponse(list))
+	}
+}
+
+// UpdateListHandler handles PUT /api/lists/{id}
+// Making a list private drops its subscribers.
+func UpdateListHandler(db *sql.DB, queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, ok := listForOwner(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		req, ok := decodeListRequest(w, r)
+		if !ok {
+			return
+		}
+
+		err := database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			var err error
+			list, err = q.UpdateList(r.Context(), database.UpdateListParams{
+				ID:          list.ID,
+				OwnerID:     list.OwnerID,
+				Name:        req.Name,
+				Description: req.Description,
+				IsPrivate:   req.Private,
+			})
+			if err != nil || !list.IsPrivate {
+				return err
+			}
+			return q.DeleteListSubscriptions(r.Context(), list.ID)
+		})
+		if err != nil {
+			logError(r, "failed to update list", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update list"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listToResponse(list))
+	}
+}
+
+// DeleteListHandler handles DELETE /api/lists/{id}
+func DeleteListHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, ok := listForOwner(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		if _, err := queries.DeleteList(r.Context(), database.DeleteListParams{
+			ID:      list.ID,
+			OwnerID: list.OwnerID,
+		}); err != nil {
+			logError(r, "failed to delete list", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete list"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListMembersHandler handles GET /api/lists/{id}/members
+func ListMembersHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, _, ok := listForViewer(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		rows, err := queries.ListListMembers(r.Context(), list.ID)
+		if err != nil {
+			logError(r, "failed to fetch members", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch members"})
+			return
+		}
+
+		resp := make([]listMemberResponse, len(rows))
+		for i, row := range rows {
+			resp[i] = listMemberResponse{
+				UserID:      row.ID.String(),
+				Handle:      row.Handle.String,
+				DisplayName: row.DisplayName,
+				AddedAt:     row.AddedAt,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// AddListMemberHandler handles POST /api/lists/{id}/members
+func AddListMemberHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, ok := listForOwner(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		var req addListMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		memberID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid user_id"})
+			return
+		}
+
+		if _, err := queries.GetUserByID(r.Context(), memberID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+			} else {
+				logError(r, "failed to fetch user", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch user"})
+			}
+			return
+		}
+
+		count, err := queries.CountListMembers(r.Context(), list.ID)
+		if err != nil {
+			logError(r, "failed to add member", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to add member"})
+			return
+		}
+		if count >= maxListMembers {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "List is full"})
+			return
+		}
+
+		if err := queries.AddListMember(r.Context(), database.AddListMemberParams{
+			ListID: list.ID,
+			UserID: memberID,
+		}); err != nil {
+			logError(r, "failed to add member", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to add member"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveListMemberHandler handles DELETE /api/lists/{id}/members/{userID}
+func RemoveListMemberHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, ok := listForOwner(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		// Expected path: /api/lists/{id}/members/{userID}
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 5 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		memberID, err := uuid.Parse(parts[4])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+
+		n, err := queries.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+			ListID: list.ID,
+			UserID: memberID,
+		})
+		if err != nil {
+			logError(r, "failed to remove member", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to remove member"})
+			return
+		}
+		if n == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Member not found"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListChirpsHandler handles GET /api/lists/{id}/chirps
+// A timeline of the list members' chirps with the same sort and
+// cursor/limit pagination as GET /api/chirps.
+func ListChirpsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, viewerID, ok := listForViewer(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+
+		params := database.ListFeedChirpsParams{
+			ListID:   uuid.NullUUID{UUID: list.ID, Valid: true},
+			ViewerID: viewerID,
+		}
+		if !parseFeedPage(w, r, &params) {
+			return
+		}
+
+		writeFeed(w, r, queries, params, nil)
+	}
+}
+
+// ListSubscriptionHandler handles POST and DELETE /api/lists/{id}/subscription
+// Users can subscribe to other users' public lists.
+func ListSubscriptionHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		list, viewerID, ok := listForViewer(w, r, queries, jwtSecret)
+		if !ok {
+			return
+		}
+		if !viewerID.Valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+		if list.OwnerID == viewerID.UUID {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot subscribe to your own list"})
+			return
+		}
+
+		var err error
+		if r.Method == http.MethodPost {
+			err = queries.SubscribeToList(r.Context(), database.SubscribeToListParams{
+				ListID: list.ID,
+				UserID: viewerID.UUID,
+			})
+		} else {
+			err = queries.UnsubscribeFromList(r.Context(), database.UnsubscribeFromListParams{
+				ListID: list.ID,
+				UserID: viewerID.UUID,
+			})
+		}
+		if err != nil {
+			logError(r, "failed to update subscription", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update subscription"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeListRequest reads and validates a list body. It writes the error
+// response and returns false otherwise.
+func decodeListRequest(w http.ResponseWriter, r *http.Request) (listRequest, bool) {
+	var req listRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+		return req, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxListNameLength {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "List name must be 1 to 50 characters"})
+		return req, false
+	}
+	if len(req.Description) > maxListDescriptionLength {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Description is too long"})
+		return req, false
+	}
+	return req, true
+}
+
+// listForViewer loads the list named in a /api/lists/{id}/... path for the
+// optional viewer. Private lists of other users are reported as missing. It
+// writes the error response and returns false otherwise.
+func listForViewer(w http.ResponseWriter, r *http.Request, queries *database.Queries, jwtSecret string) (database.List, uuid.NullUUID, bool) {
+	viewerID, err := optionalViewer(r, jwtSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid token"})
+		return database.List{}, uuid.NullUUID{}, false
+	}
+
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 3 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+		return database.List{}, uuid.NullUUID{}, false
+	}
+	listID, err := uuid.Parse(parts[2])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid list ID"})
+		return database.List{}, uuid.NullUUID{}, false
+	}
+
+	list, err := queries.GetList(r.Context(), listID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logError(r, "failed to fetch list", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch list"})
+		return database.List{}, uuid.NullUUID{}, false
+	}
+	if err != nil || (list.IsPrivate && (!viewerID.Valid || viewerID.UUID != list.OwnerID)) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "List not found"})
+		return database.List{}, uuid.NullUUID{}, false
+	}
+
+	return list, viewerID, true
+}
+
+// listForOwner is listForViewer for requests only the list owner may make
+func listForOwner(w http.ResponseWriter, r *http.Request, queries *database.Queries, jwtSecret string) (database.List, bool) {
+	if r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+		return database.List{}, false
+	}
+
+	list, viewerID, ok := listForViewer(w, r, queries, jwtSecret)
+	if !ok {
+		return database.List{}, false
+	}
+	if viewerID.UUID != list.OwnerID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only the list owner can change it"})
+		return database.List{}, false
+	}
+	return list, true
+}
@@ -7,11 +7,10 @@ import (
 
 	"github.com/xaitan80/go-server/internal/auth"
 	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/metrics"
+	"github.com/xaitan80/go-server/internal/websec"
 )
 
-// Token TTL for access tokens
-const accessTokenTTL = time.Hour * 24
-
 // Request struct for login
 type loginRequest struct {
 	Email    string `json:"email"`
@@ -22,10 +21,16 @@ type loginRequest struct {
 type loginResponse struct {
 	Email string `json:"email"`
 	Token string `json:"token"`
+	// CSRFToken is set with sessions, for web clients on another origin
+	// that can't read the CSRF cookie
+	CSRFToken string `json:"csrf_token,omitempty"`
 }
 
 // LoginHandler handles POST /api/login
-func LoginHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+// Issued access tokens are valid for accessTokenTTL. With sessions, the
+// token is also set as a session cookie along with a CSRF cookie, and the
+// CSRF token is returned in the response.
+func LoginHandler(queries *database.Queries, jwtSecret string, accessTokenTTL time.Duration, sessions *websec.Sessions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -44,6 +49,7 @@ func LoginHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc
 		// Get user by email
 		user, err := queries.GetUserByEmail(r.Context(), req.Email)
 		if err != nil {
+			metrics.Logins.WithLabelValues("invalid_credentials").Inc()
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid credentials"})
 			return
@@ -51,23 +57,56 @@ func LoginHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc
 
 		// Check password hash
 		if err := auth.CheckPasswordHash(req.Password, user.HashedPassword.String); err != nil {
+			metrics.Logins.WithLabelValues("invalid_credentials").Inc()
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid credentials"})
 			return
 		}
 
+		if isSuspended(user) {
+			metrics.Logins.WithLabelValues("suspended").Inc()
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Account suspended until " + user.SuspendedUntil.Time.Format(time.RFC3339)})
+			return
+		}
+
+		// Logging in during the grace period cancels a pending deletion
+		if user.DeletionRequestedAt.Valid {
+			if err := queries.CancelUserDeletion(r.Context(), user.ID); err != nil {
+				logError(r, "failed to restore account", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to restore account"})
+				return
+			}
+		}
+
 		// Generate JWT access token
 		accessToken, err := auth.MakeJWT(user.ID, jwtSecret, accessTokenTTL)
 		if err != nil {
+			logError(r, "failed to generate token", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to generate token"})
 			return
 		}
 
+		var csrfToken string
+		if sessions != nil {
+			csrfToken, err = sessions.Start(w, accessToken, accessTokenTTL)
+			if err != nil {
+				logError(r, "failed to start session", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to start session"})
+				return
+			}
+		}
+
+		metrics.Logins.WithLabelValues("success").Inc()
+
 		// Return token + email
 		resp := loginResponse{
-			Email: user.Email,
-			Token: accessToken,
+			Email:     user.Email,
+			Token:     accessToken,
+			CSRFToken: csrfToken,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/xaitan80/go-server/internal/websec"
+)
+
+// LogoutHandler handles POST /api/logout by clearing the session cookies.
+// The access token itself stays valid until it expires.
+func LogoutHandler(sessions *websec.Sessions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		sessions.End(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
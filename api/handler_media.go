@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+)
+
+// Most attachments a chirp can carry
+const maxAttachments = 4
+
+var errInvalidMedia = errors.New("invalid media")
+
+// AttachmentResponse is an uploaded image as shown on chirps
+type AttachmentResponse struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+}
+
+func mediaToResponse(m database.Medium) AttachmentResponse {
+	return AttachmentResponse{
+		ID:           m.ID.String(),
+		URL:          m.Url,
+		ThumbnailURL: m.ThumbnailUrl,
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+	}
+}
+
+// UploadMediaHandler handles POST /api/media
+// Expects a multipart form with the image in the "file" field. The type is
+// sniffed from the content; the returned ID can be passed in media_ids when
+// creating a chirp.
+func UploadMediaHandler(queries *database.Queries, store media.BlobStore, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Leave room for the multipart framing around the file
+		r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+64<<10)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "File is too large"})
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Missing file"})
+			}
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to read file"})
+			return
+		}
+
+		img, err := media.ProcessImage(data)
+		switch {
+		case errors.Is(err, media.ErrTooLarge):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "File is too large"})
+			return
+		case errors.Is(err, media.ErrUnsupportedType):
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Only JPEG, PNG, GIF and WebP images are supported"})
+			return
+		case err != nil:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid image"})
+			return
+		}
+
+		id := uuid.New()
+		key := "media/" + id.String() + img.Extension
+		thumbKey := "media/" + id.String() + "_thumb" + thumbnailExtension(img.ThumbnailType)
+		if err := store.Put(r.Context(), key, data, img.ContentType); err != nil {
+			logError(r, "failed to store file", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to store file"})
+			return
+		}
+		if err := store.Put(r.Context(), thumbKey, img.Thumbnail, img.ThumbnailType); err != nil {
+			store.Delete(r.Context(), key)
+			logError(r, "failed to store file", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to store file"})
+			return
+		}
+
+		m, err := queries.CreateMedia(r.Context(), database.CreateMediaParams{
+			UserID:       userID,
+			ContentType:  img.ContentType,
+			SizeBytes:    int64(len(data)),
+			Width:        int32(img.Width),
+			Height:       int32(img.Height),
+			StorageKey:   key,
+			Url:          store.URL(key),
+			ThumbnailKey: thumbKey,
+			ThumbnailUrl: store.URL(thumbKey),
+		})
+		if err != nil {
+			store.Delete(r.Context(), key)
+			store.Delete(r.Context(), thumbKey)
+			logError(r, "failed to save media", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to save media"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(mediaToResponse(m))
+	}
+}
+
+func thumbnailExtension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// parseMediaIDs validates the media_ids of a chirp request
+func parseMediaIDs(raw []string) ([]uuid.UUID, error) {
+	if len(raw) > maxAttachments {
+		return nil, errInvalidMedia
+	}
+	ids := make([]uuid.UUID, 0, len(raw))
+	seen := map[uuid.UUID]bool{}
+	for _, s := range raw {
+		id, err := uuid.Parse(s)
+		if err != nil || seen[id] {
+			return nil, errInvalidMedia
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// attachMedia links the caller's unattached uploads to a new chirp, in the
+// order given. It fails with errInvalidMedia if any ID isn't usable.
+func attachMedia(ctx context.Context, q *database.Queries, chirp database.Chirp, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+	n, err := q.AttachMediaToChirp(ctx, database.AttachMediaToChirpParams{
+		ChirpID:  chirp.ID,
+		MediaIds: mediaIDs,
+		UserID:   chirp.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	if n != int64(len(mediaIDs)) {
+		return errInvalidMedia
+	}
+	return nil
+}
+
+// attachmentsByChirp loads the attachments of the given chirps
+func attachmentsByChirp(ctx context.Context, q *database.Queries, chirpIDs []uuid.UUID) (map[uuid.UUID][]AttachmentResponse, error) {
+	out := map[uuid.UUID][]AttachmentResponse{}
+	if len(chirpIDs) == 0 {
+		return out, nil
+	}
+	rows, err := q.ListMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range rows {
+		out[m.ChirpID.UUID] = append(out[m.ChirpID.UUID], mediaToResponse(m))
+	}
+	return out, nil
+}
+
+// attachChirpMedia fills in Attachments on chirp responses
+func attachChirpMedia(ctx context.Context, q *database.Queries, resps []ChirpResponse) error {
+	ids := make([]uuid.UUID, 0, len(resps))
+	for _, r := range resps {
+		id, err := uuid.Parse(r.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	byChirp, err := attachmentsByChirp(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range resps {
+		resps[i].Attachments = byChirp[ids[i]]
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/authz"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+// Reasons a chirp can be reported for
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "misinformation", "other"}
+
+// Moderator decisions on a report
+const (
+	actionDismiss = "dismiss"
+	actionHide    = "hide"
+	actionDelete  = "delete"
+	actionSuspend = "suspend"
+)
+
+const maxReportDetailsLength = 500
+
+var errReportResolved = errors.New("report already resolved")
+
+type reportResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpID   string    `json:"chirp_id"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details"`
+}
+
+type reportQueueItem struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ChirpID     string    `json:"chirp_id"`
+	ChirpBody   string    `json:"chirp_body"`
+	AuthorID    string    `json:"author_id"`
+	Hidden      bool      `json:"hidden"`
+	ReporterID  string    `json:"reporter_id"`
+	Reason      string    `json:"reason"`
+	Details     string    `json:"details"`
+	OpenReports int64     `json:"open_reports"`
+}
+
+type moderationActionResponse struct {
+	ID             string     `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ModeratorID    string     `json:"moderator_id,omitempty"`
+	Action         string     `json:"action"`
+	ChirpID        string     `json:"chirp_id,omitempty"`
+	ChirpBody      string     `json:"chirp_body"`
+	TargetUserID   string     `json:"target_user_id"`
+	Reason         string     `json:"reason"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+func moderationActionToResponse(a database.ModerationAction) moderationActionResponse {
+	resp := moderationActionResponse{
+		ID:           a.ID.String(),
+		CreatedAt:    a.CreatedAt,
+		Action:       a.Action,
+		ChirpBody:    a.ChirpBody,
+		TargetUserID: a.TargetUserID.String(),
+		Reason:       a.Reason,
+	}
+	if a.ModeratorID.Valid {
+		resp.ModeratorID = a.ModeratorID.UUID.String()
+	}
+	if a.ChirpID.Valid {
+		resp.ChirpID = a.ChirpID.UUID.String()
+	}
+	if a.SuspendedUntil.Valid {
+		resp.SuspendedUntil = &a.SuspendedUntil.Time
+	}
+	return resp
+}
+
+// isSuspended reports whether the user is currently suspended
+func isSuspended(user database.User) bool {
+	return user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(time.Now())
+}
+
+// ReportChirpHandler handles POST /api/chirps/{id}/report
+func ReportChirpHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Expected path: /api/chirps/{id}/report
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		chirpID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid chirp ID"})
+			return
+		}
+
+		var req struct {
+			Reason  string `json:"reason"`
+			Details string `json:"details"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		validReason := false
+		for _, reason := range reportReasons {
+			if req.Reason == reason {
+				validReason = true
+				break
+			}
+		}
+		if !validReason {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown report reason"})
+			return
+		}
+		if len(req.Details) > maxReportDetailsLength {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Details are too long"})
+			return
+		}
+
+		chirp, err := visibleChirp(r.Context(), queries, chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			} else {
+				logError(r, "failed to fetch chirp", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirp"})
+			}
+			return
+		}
+		if chirp.UserID == userID {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You cannot report your own chirp"})
+			return
+		}
+
+		report, err := queries.CreateChirpReport(r.Context(), database.CreateChirpReportParams{
+			ChirpID:    chirpID,
+			ReporterID: userID,
+			Reason:     req.Reason,
+			Details:    req.Details,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "You already reported this chirp"})
+			} else {
+				logError(r, "failed to report chirp", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to report chirp"})
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(reportResponse{
+			ID:        report.ID.String(),
+			CreatedAt: report.CreatedAt,
+			ChirpID:   report.ChirpID.String(),
+			Reason:    report.Reason,
+			Details:   report.Details,
+		})
+	}
+}
+
+// ListReportsHandler handles GET /admin/reports
+// Returns open reports oldest first, with cursor and limit pagination.
+func ListReportsHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListOpenReportsParams{RowLimit: limit}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListOpenReports(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch reports", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch reports"})
+			return
+		}
+
+		resp := make([]reportQueueItem, len(rows))
+		for i, row := range rows {
+			resp[i] = reportQueueItem{
+				ID:          row.ID.String(),
+				CreatedAt:   row.CreatedAt,
+				ChirpID:     row.ChirpID.String(),
+				ChirpBody:   row.ChirpBody,
+				AuthorID:    row.AuthorID.String(),
+				Hidden:      row.HiddenAt.Valid,
+				ReporterID:  row.ReporterID.String(),
+				Reason:      row.Reason,
+				Details:     row.Details,
+				OpenReports: row.OpenReports,
+			}
+		}
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			w.Header().Set("X-Next-Cursor", encodeCursor(last.CreatedAt, last.ID))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ResolveReportHandler handles POST /admin/reports/{id}/actions
+// The action applies to the reported chirp and resolves every open report
+// on it. Suspending the author also requires the users:suspend permission.
+func ResolveReportHandler(db *sql.DB, policy *authz.Policy, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		moderatorID, ok := authorize(w, r, policy, jwtSecret, authz.PermReportsReview)
+		if !ok {
+			return
+		}
+
+		// Expected path: /admin/reports/{id}/actions
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		reportID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid report ID"})
+			return
+		}
+
+		var req struct {
+			Action   string `json:"action"`
+			Reason   string `json:"reason"`
+			Duration string `json:"duration"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+
+		var suspendedUntil sql.NullTime
+		switch req.Action {
+		case actionDismiss, actionHide, actionDelete:
+		case actionSuspend:
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid duration"})
+				return
+			}
+			if _, ok := authorize(w, r, policy, jwtSecret, authz.PermUsersSuspend); !ok {
+				return
+			}
+			suspendedUntil = sql.NullTime{Time: time.Now().Add(d), Valid: true}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown action"})
+			return
+		}
+
+		// Apply the decision and record it in the audit trail atomically
+		var action database.ModerationAction
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			report, err := q.GetChirpReport(r.Context(), reportID)
+			if err != nil {
+				return err
+			}
+			if report.ResolvedAt.Valid {
+				return errReportResolved
+			}
+			chirp, err := q.GetChirpByIDWithDeleted(r.Context(), report.ChirpID)
+			if err != nil {
+				return err
+			}
+
+			if err := q.ResolveChirpReports(r.Context(), chirp.ID); err != nil {
+				return err
+			}
+
+			switch req.Action {
+			case actionHide:
+				err = q.HideChirp(r.Context(), chirp.ID)
+			case actionDelete:
+				if err = q.DeleteChirp(r.Context(), database.DeleteChirpParams{
+					ID:        chirp.ID,
+					DeletedBy: uuid.NullUUID{UUID: moderatorID, Valid: true},
+				}); err == nil {
+					err = outbox.Enqueue(r.Context(), q, outbox.TopicChirpDeleted, chirp.ID, chirpToResponse(chirp))
+				}
+			case actionSuspend:
+				if err = q.SuspendUser(r.Context(), database.SuspendUserParams{
+					ID:             chirp.UserID,
+					SuspendedUntil: suspendedUntil,
+				}); err == nil {
+					err = q.RevokeUserRefreshTokens(r.Context(), chirp.UserID)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			action, err = q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+				ModeratorID:    uuid.NullUUID{UUID: moderatorID, Valid: true},
+				Action:         req.Action,
+				ChirpID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+				ChirpBody:      chirp.Body,
+				TargetUserID:   chirp.UserID,
+				Reason:         req.Reason,
+				SuspendedUntil: suspendedUntil,
+			})
+			return err
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Report not found"})
+			return
+		case errors.Is(err, errReportResolved):
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Report already resolved"})
+			return
+		case err != nil:
+			logError(r, "failed to apply action", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to apply action"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(moderationActionToResponse(action))
+	}
+}
+
+// ListModerationActionsHandler handles GET /admin/moderation/actions
+// Returns the audit trail newest first, with cursor and limit pagination.
+func ListModerationActionsHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListModerationActionsParams{RowLimit: limit}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListModerationActions(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch moderation log", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch moderation log"})
+			return
+		}
+
+		resp := make([]moderationActionResponse, len(rows))
+		for i, a := range rows {
+			resp[i] = moderationActionToResponse(a)
+		}
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			w.Header().Set("X-Next-Cursor", encodeCursor(last.CreatedAt, last.ID))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/notifications"
+)
+
+// Response struct for a page of notifications
+type notificationsResponse struct {
+	Notifications []notifications.Notification `json:"notifications"`
+	UnreadCount   int64                        `json:"unread_count"`
+	NextCursor    string                       `json:"next_cursor,omitempty"`
+}
+
+// ListNotificationsHandler handles GET /api/notifications
+// Supports cursor, limit and unread=true query parameters.
+func ListNotificationsHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListNotificationsParams{
+			UserID:     userID,
+			UnreadOnly: r.URL.Query().Get("unread") == "true",
+			RowLimit:   limit,
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+			params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		rows, err := queries.ListNotifications(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch notifications", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch notifications"})
+			return
+		}
+
+		unread, err := queries.CountUnreadNotifications(r.Context(), userID)
+		if err != nil {
+			logError(r, "failed to count notifications", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to count notifications"})
+			return
+		}
+
+		resp := notificationsResponse{
+			Notifications: make([]notifications.Notification, len(rows)),
+			UnreadCount:   unread,
+		}
+		for i, n := range rows {
+			resp.Notifications[i] = notifications.FromRow(n)
+		}
+		if len(rows) == int(limit) {
+			last := rows[len(rows)-1]
+			resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// MarkNotificationReadHandler handles POST /api/notifications/{id}/read
+func MarkNotificationReadHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Expected path: /api/notifications/{id}/read
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		notificationID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid notification ID"})
+			return
+		}
+
+		n, err := queries.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+			ID:     notificationID,
+			UserID: userID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Notification not found"})
+			} else {
+				logError(r, "failed to update notification", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update notification"})
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notifications.FromRow(n))
+	}
+}
+
+// MarkAllNotificationsReadHandler handles POST /api/notifications/read-all
+func MarkAllNotificationsReadHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		updated, err := queries.MarkAllNotificationsRead(r.Context(), userID)
+		if err != nil {
+			logError(r, "failed to update notifications", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update notifications"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Updated int64 `json:"updated"`
+		}{Updated: updated})
+	}
+}
+
+// NotificationPreferencesHandler handles GET and PUT /api/notifications/preferences
+// Preferences are a map of notification type to enabled; types without a
+// stored preference are enabled.
+func NotificationPreferencesHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var req map[string]bool
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+				return
+			}
+
+			for typ, enabled := range req {
+				if !notifications.IsValidType(typ) {
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown notification type: " + typ})
+					return
+				}
+				if err := queries.UpsertNotificationPreference(r.Context(), database.UpsertNotificationPreferenceParams{
+					UserID:  userID,
+					Type:    typ,
+					Enabled: enabled,
+				}); err != nil {
+					logError(r, "failed to update preferences", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update preferences"})
+					return
+				}
+			}
+		}
+
+		stored, err := queries.ListNotificationPreferences(r.Context(), userID)
+		if err != nil {
+			logError(r, "failed to fetch preferences", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch preferences"})
+			return
+		}
+
+		resp := make(map[string]bool, len(notifications.Types))
+		for _, typ := range notifications.Types {
+			resp[typ] = true
+		}
+		for _, p := range stored {
+			resp[p.Type] = p.Enabled
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
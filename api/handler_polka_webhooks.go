@@ -3,12 +3,16 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/xaitan80/go-server/internal/auth"
 	"github.com/xaitan80/go-server/internal/config"
 	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/metrics"
+	"github.com/xaitan80/go-server/internal/notifications"
+	"github.com/xaitan80/go-server/internal/outbox"
 )
 
 // PolkaWebhookRequest represents the shape of incoming webhook requests from Polka
@@ -20,7 +24,7 @@ type PolkaWebhookRequest struct {
 }
 
 // PolkaWebhooksHandler handles POST /api/polka/webhooks
-func PolkaWebhooksHandler(queries *database.Queries, cfg *config.APIConfig) http.HandlerFunc {
+func PolkaWebhooksHandler(db *sql.DB, cfg *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only allow POST
 		if r.Method != http.MethodPost {
@@ -31,7 +35,7 @@ func PolkaWebhooksHandler(queries *database.Queries, cfg *config.APIConfig) http
 
 		// Check Polka API key
 		key, err := auth.GetAPIKey(r.Header)
-		if err != nil || key != cfg.PolkaKey {
+		if err != nil || key != cfg.Auth.PolkaKey {
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing Polka API key"})
 			return
@@ -45,6 +49,8 @@ func PolkaWebhooksHandler(queries *database.Queries, cfg *config.APIConfig) http
 			return
 		}
 
+		metrics.WebhookEvents.WithLabelValues(webhookEventLabel(req.Event)).Inc()
+
 		// Ignore all events except "user.upgraded"
 		if req.Event != "user.upgraded" {
 			w.WriteHeader(http.StatusNoContent)
@@ -59,14 +65,48 @@ func PolkaWebhooksHandler(queries *database.Queries, cfg *config.APIConfig) http
 			return
 		}
 
-		// Upgrade user to Chirpy Red
-		if err := queries.UpgradeUserToChirpyRed(r.Context(), userID); err != nil {
-			// Assume ErrNoRows means user not found
-			if err == sql.ErrNoRows {
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
-				return
+		details, err := json.Marshal(req.Data)
+		if err != nil {
+			logError(r, "failed to encode billing details", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to upgrade user"})
+			return
+		}
+
+		// Upgrade user to Chirpy Red and record the billing event atomically.
+		// A redelivered webhook finds the user already upgraded and changes
+		// nothing.
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			n, err := q.UpgradeUserToChirpyRed(r.Context(), userID)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				// Either unknown or already upgraded
+				_, err := q.GetUserByID(r.Context(), userID)
+				return err
 			}
+			if err := q.CreateBillingEvent(r.Context(), database.CreateBillingEventParams{
+				UserID:  userID,
+				Event:   req.Event,
+				Details: details,
+			}); err != nil {
+				return err
+			}
+			if err := notifications.ForUpgrade(r.Context(), q, userID); err != nil {
+				return err
+			}
+			return outbox.Enqueue(r.Context(), q, outbox.TopicUserUpgraded, userID, struct {
+				UserID string `json:"user_id"`
+			}{UserID: userID.String()})
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+			return
+		}
+		if err != nil {
+			logError(r, "failed to upgrade user", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to upgrade user"})
 			return
@@ -76,3 +116,12 @@ func PolkaWebhooksHandler(queries *database.Queries, cfg *config.APIConfig) http
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// webhookEventLabel bounds the event label of the webhook metric to the
+// events we handle
+func webhookEventLabel(event string) string {
+	if event == "user.upgraded" {
+		return event
+	}
+	return "other"
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Poll limits
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// pollRequest is the optional poll in a chirp request
+type pollRequest struct {
+	Options     []string   `json:"options"`
+	ClosesAt    *time.Time `json:"closes_at"`
+	HideResults bool       `json:"hide_results,omitempty"`
+}
+
+// PollResponse is a poll as shown on chirps. Votes are omitted while the
+// results are hidden from the viewer.
+type PollResponse struct {
+	ID            string               `json:"id"`
+	ClosesAt      time.Time            `json:"closes_at"`
+	Closed        bool                 `json:"closed"`
+	ResultsHidden bool                 `json:"results_hidden,omitempty"`
+	TotalVotes    *int64               `json:"total_votes,omitempty"`
+	VotedOptionID string               `json:"voted_option_id,omitempty"`
+	Options       []PollOptionResponse `json:"options"`
+}
+
+// PollOptionResponse is one choice of a poll
+type PollOptionResponse struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type pollVoteRequest struct {
+	OptionID string `json:"option_id"`
+}
+
+// validatePoll checks a poll request for a chirp published at publishAt and
+// returns the trimmed option labels, or an error message
+func validatePoll(req *pollRequest, publishAt time.Time) ([]string, string) {
+	if len(req.Options) < minPollOptions || len(req.Options) > maxPollOptions {
+		return nil, "A poll needs 2 to 4 options"
+	}
+
+	labels := make([]string, 0, len(req.Options))
+	seen := map[string]bool{}
+	for _, o := range req.Options {
+		label := strings.TrimSpace(o)
+		if label == "" || len(label) > maxPollOptionLength {
+			return nil, "Poll options must be 1 to 25 characters"
+		}
+		if seen[strings.ToLower(label)] {
+			return nil, "Poll options must be unique"
+		}
+		seen[strings.ToLower(label)] = true
+		labels = append(labels, label)
+	}
+
+	if req.ClosesAt == nil {
+		return nil, "Poll closes_at is required"
+	}
+	if errMsg := validatePollWindow(*req.ClosesAt, publishAt); errMsg != "" {
+		return nil, errMsg
+	}
+	return labels, ""
+}
+
+// validatePollWindow checks that a poll closing at closesAt stays open long
+// enough, but not too long, for a chirp published at publishAt
+func validatePollWindow(closesAt, publishAt time.Time) string {
+	d := closesAt.Sub(publishAt)
+	if d < minPollDuration || d > maxPollDuration {
+		return "Poll must close between 5 minutes and 7 days after publishing"
+	}
+	return ""
+}
+
+// createPoll stores a validated poll for chirpID
+func createPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, req *pollRequest, labels []string) error {
+	poll, err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:     chirpID,
+		ClosesAt:    req.ClosesAt.UTC(),
+		HideResults: req.HideResults,
+	})
+	if err != nil {
+		return err
+	}
+	return q.CreatePollOptions(ctx, database.CreatePollOptionsParams{
+		PollID: poll.ID,
+		Labels: labels,
+	})
+}
+
+// pollsByChirp loads the polls of the given chirps with their tallies as
+// seen by viewerID. Results of polls with hide_results stay hidden until the
+// viewer has voted or the poll closes; the author always sees them.
+func pollsByChirp(ctx context.Context, q *database.Queries, chirpIDs []uuid.UUID, viewerID uuid.NullUUID) (map[uuid.UUID]*PollResponse, error) {
+	out := map[uuid.UUID]*PollResponse{}
+	if len(chirpIDs) == 0 {
+		return out, nil
+	}
+	polls, err := q.ListPollsForChirps(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return out, err
+	}
+
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, p := range polls {
+		pollIDs[i] = p.ID
+	}
+	tallies, err := q.ListPollOptionTallies(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	voted := map[uuid.UUID]uuid.UUID{}
+	if viewerID.Valid {
+		votes, err := q.ListUserPollVotes(ctx, database.ListUserPollVotesParams{
+			UserID:  viewerID,
+			PollIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range votes {
+			voted[v.PollID] = v.OptionID
+		}
+	}
+
+	byPoll := map[uuid.UUID]*PollResponse{}
+	now := time.Now()
+	for _, p := range polls {
+		optionID, hasVoted := voted[p.ID]
+		resp := &PollResponse{
+			ID:       p.ID.String(),
+			ClosesAt: p.ClosesAt,
+			Closed:   !p.ClosesAt.After(now),
+			Options:  []PollOptionResponse{},
+		}
+		if hasVoted {
+			resp.VotedOptionID = optionID.String()
+		}
+		isAuthor := viewerID.Valid && viewerID.UUID == p.AuthorID
+		resp.ResultsHidden = p.HideResults && !resp.Closed && !hasVoted && !isAuthor
+		if !resp.ResultsHidden {
+			resp.TotalVotes = new(int64)
+		}
+		byPoll[p.ID] = resp
+		out[p.ChirpID] = resp
+	}
+
+	for _, t := range tallies {
+		resp := byPoll[t.PollID]
+		option := PollOptionResponse{ID: t.ID.String(), Label: t.Label}
+		if !resp.ResultsHidden {
+			votes := t.Votes
+			option.Votes = &votes
+			*resp.TotalVotes += t.Votes
+		}
+		resp.Options = append(resp.Options, option)
+	}
+	return out, nil
+}
+
+// attachChirpPolls fills in Poll on chirp responses
+func attachChirpPolls(ctx context.Context, q *database.Queries, resps []ChirpResponse, viewerID uuid.NullUUID) error {
+	ids := make([]uuid.UUID, 0, len(resps))
+	for _, r := range resps {
+		id, err := uuid.Parse(r.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	byChirp, err := pollsByChirp(ctx, q, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range resps {
+		resps[i].Poll = byChirp[ids[i]]
+	}
+	return nil
+}
+
+// VotePollHandler handles POST /api/chirps/{chirpID}/poll/votes
+// Each user votes once; votes are rejected once the poll has closed.
+func VotePollHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Expected path: /api/chirps/{chirpID}/poll/votes
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 5 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		chirpID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid UUID"})
+			return
+		}
+
+		var req pollVoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		optionID, err := uuid.Parse(req.OptionID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid option_id"})
+			return
+		}
+
+		_, err = visibleChirp(r.Context(), queries, chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			return
+		}
+
+		poll, err := queries.GetPollByChirpID(r.Context(), chirpID)
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Poll not found"})
+			return
+		}
+		if err != nil {
+			logError(r, "failed to record vote", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to record vote"})
+			return
+		}
+		if !poll.ClosesAt.After(time.Now()) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Poll is closed"})
+			return
+		}
+
+		// The insert re-checks the option and closing time in the database
+		_, err = queries.CastPollVote(r.Context(), database.CastPollVoteParams{
+			UserID:   uuid.NullUUID{UUID: userID, Valid: true},
+			PollID:   poll.ID,
+			OptionID: optionID,
+		})
+		if isUniqueViolation(err) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You have already voted"})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			if !poll.ClosesAt.After(time.Now()) {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Poll is closed"})
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid option_id"})
+			return
+		}
+		if err != nil {
+			logError(r, "failed to record vote", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to record vote"})
+			return
+		}
+
+		polls, err := pollsByChirp(r.Context(), queries, []uuid.UUID{chirpID}, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			logError(r, "failed to fetch poll", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch poll"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(polls[chirpID])
+	}
+}
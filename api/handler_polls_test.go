@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidatePollWindow(t *testing.T) {
+	publishAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		closesAt time.Time
+		wantOK   bool
+	}{
+		{"an hour", publishAt.Add(time.Hour), true},
+		{"shortest", publishAt.Add(minPollDuration), true},
+		{"longest", publishAt.Add(maxPollDuration), true},
+		{"too short", publishAt.Add(time.Minute), false},
+		{"too long", publishAt.Add(maxPollDuration + time.Second), false},
+		// A draft published after its poll was meant to close
+		{"already closed", publishAt.Add(-time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errMsg := validatePollWindow(tt.closesAt, publishAt)
+			if (errMsg == "") != tt.wantOK {
+				t.Errorf("validatePollWindow = %q, want ok %v", errMsg, tt.wantOK)
+			}
+		})
+	}
+}
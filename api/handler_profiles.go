@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Profile field limits
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 50
+	maxURLLength         = 200
+)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// Response struct for public user data (never includes email)
+type publicUserResponse struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+// GetUserHandler handles GET /api/users/{id}
+func GetUserHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		// Expected path: /api/users/{id}
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		userID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid user ID"})
+			return
+		}
+
+		user, err := queries.GetUserByID(r.Context(), userID)
+		writePublicUser(w, r, user, err)
+	}
+}
+
+// GetUserByHandleHandler handles GET /api/users/by-handle/{handle}
+func GetUserByHandleHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		// Expected path: /api/users/by-handle/{handle}
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 || !handlePattern.MatchString(parts[3]) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid handle"})
+			return
+		}
+
+		user, err := queries.GetUserByHandle(r.Context(), parts[3])
+		writePublicUser(w, r, user, err)
+	}
+}
+
+// writePublicUser writes the result of a user lookup
+func writePublicUser(w http.ResponseWriter, r *http.Request, user database.User, err error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found"})
+		} else {
+			logError(r, "failed to fetch user", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch user"})
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userToPublicResponse(user))
+}
+
+// userToPublicResponse converts a database user into its public representation
+func userToPublicResponse(u database.User) publicUserResponse {
+	return publicUserResponse{
+		ID:          u.ID.String(),
+		CreatedAt:   u.CreatedAt,
+		Handle:      u.Handle.String,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		AvatarURL:   u.AvatarUrl,
+		Location:    u.Location,
+		Website:     u.Website,
+		IsChirpyRed: u.IsChirpyRed,
+	}
+}
+
+// validateProfile checks the optional profile fields of an update request
+func validateProfile(req updateUserRequest) error {
+	if req.Handle != nil && !handlePattern.MatchString(*req.Handle) {
+		return errors.New("Handle must be 3-30 letters, digits or underscores")
+	}
+	if req.DisplayName != nil && utf8.RuneCountInString(*req.DisplayName) > maxDisplayNameLength {
+		return errors.New("Display name is too long")
+	}
+	if req.Bio != nil && utf8.RuneCountInString(*req.Bio) > maxBioLength {
+		return errors.New("Bio is too long")
+	}
+	if req.Location != nil && utf8.RuneCountInString(*req.Location) > maxLocationLength {
+		return errors.New("Location is too long")
+	}
+	if req.AvatarURL != nil && !isValidProfileURL(*req.AvatarURL) {
+		return errors.New("Invalid avatar_url")
+	}
+	if req.Website != nil && !isValidProfileURL(*req.Website) {
+		return errors.New("Invalid website")
+	}
+	return nil
+}
+
+// isValidProfileURL accepts an empty string (to clear the field) or an
+// absolute http(s) URL
+func isValidProfileURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	if len(raw) > maxURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// attachAuthorHandles fills in AuthorHandle for each chirp
+func attachAuthorHandles(ctx context.Context, queries *database.Queries, chirps []ChirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, c := range chirps {
+		id, err := uuid.Parse(c.UserID)
+		if err == nil && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	rows, err := queries.GetUserHandlesByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	handles := make(map[string]string, len(rows))
+	for _, row := range rows {
+		handles[row.ID.String()] = row.Handle.String
+	}
+
+	for i := range chirps {
+		chirps[i].AuthorHandle = handles[chirps[i].UserID]
+	}
+	return nil
+}
+
+// nullString converts an optional request field into a query argument
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
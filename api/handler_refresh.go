@@ -9,8 +9,9 @@ import (
 	"github.com/xaitan80/go-server/internal/database"
 )
 
-// RefreshHandler creates a new access token for a valid refresh token
-func RefreshHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+// RefreshHandler creates a new access token, valid for accessTokenTTL, for a
+// valid refresh token
+func RefreshHandler(queries *database.Queries, jwtSecret string, accessTokenTTL time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract refresh token from Authorization header
 		tokenStr, err := auth.GetBearerToken(r.Header)
@@ -28,9 +29,10 @@ func RefreshHandler(queries *database.Queries, jwtSecret string) http.HandlerFun
 			return
 		}
 
-		// Generate new JWT access token (expires in 1 hour)
-		accessToken, err := auth.MakeJWT(rt.UserID, jwtSecret, time.Hour)
+		// Generate new JWT access token
+		accessToken, err := auth.MakeJWT(rt.UserID, jwtSecret, accessTokenTTL)
 		if err != nil {
+			logError(r, "failed to generate token", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to generate token"})
 			return
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/authz"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+// How long authors can restore a chirp they deleted
+const chirpRestoreWindow = 7 * 24 * time.Hour
+
+type deletedChirpResponse struct {
+	ChirpResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by,omitempty"`
+}
+
+// RestoreChirpHandler handles POST /api/chirps/{id}/restore
+// Authors can restore chirps they deleted themselves within
+// chirpRestoreWindow; moderators can restore any deleted chirp until it is
+// purged.
+func RestoreChirpHandler(db *sql.DB, queries *database.Queries, policy *authz.Policy, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Expected path: /api/chirps/{id}/restore
+		parts := splitPath(r.URL.Path)
+		if len(parts) != 4 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid path"})
+			return
+		}
+		chirpID, err := uuid.Parse(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid chirp ID"})
+			return
+		}
+
+		isModerator, err := policy.Can(r.Context(), userID, authz.PermReportsReview)
+		if err != nil {
+			logError(r, "failed to check permissions", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to check permissions"})
+			return
+		}
+
+		chirp, err := queries.GetChirpByIDWithDeleted(r.Context(), chirpID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logError(r, "failed to fetch chirp", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirp"})
+			return
+		}
+		// Other users' deleted chirps don't exist as far as they can tell
+		if err != nil || !chirp.DeletedAt.Valid || (!isModerator && chirp.UserID != userID) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			return
+		}
+
+		if !isModerator {
+			if chirp.DeletedBy.UUID != userID {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "This chirp was removed by a moderator"})
+				return
+			}
+			if time.Since(chirp.DeletedAt.Time) > chirpRestoreWindow {
+				w.WriteHeader(http.StatusGone)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Restore window has passed"})
+				return
+			}
+		}
+
+		var restored database.Chirp
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			restored, err = q.RestoreChirp(r.Context(), chirpID)
+			if err != nil {
+				return err
+			}
+			return outbox.Enqueue(r.Context(), q, outbox.TopicChirpRestored, restored.ID, chirpToResponse(restored))
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Chirp not found"})
+			} else {
+				logError(r, "failed to restore chirp", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to restore chirp"})
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chirpToResponse(restored))
+	}
+}
+
+// ListDeletedChirpsHandler handles GET /admin/chirps/deleted
+// Returns deleted chirps newest deletion first, with optional author_id and
+// cursor and limit pagination.
+func ListDeletedChirpsHandler(queries *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+
+		params := database.ListDeletedChirpsParams{RowLimit: limit}
+		if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+			authorID, err := uuid.Parse(authorIDStr)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid author_id"})
+				return
+			}
+			params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+		}
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			deletedAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid cursor"})
+				return
+			}
+			params.CursorDeletedAt = sql.NullTime{Time: deletedAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		chirps, err := queries.ListDeletedChirps(r.Context(), params)
+		if err != nil {
+			logError(r, "failed to fetch chirps", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch chirps"})
+			return
+		}
+
+		resp := make([]deletedChirpResponse, len(chirps))
+		for i, c := range chirps {
+			resp[i] = deletedChirpResponse{
+				ChirpResponse: chirpToResponse(c),
+				DeletedAt:     c.DeletedAt.Time,
+			}
+			if c.DeletedBy.Valid {
+				resp[i].DeletedBy = c.DeletedBy.UUID.String()
+			}
+		}
+		if len(chirps) == int(limit) {
+			last := chirps[len(chirps)-1]
+			w.Header().Set("X-Next-Cursor", encodeCursor(last.DeletedAt.Time, last.ID))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
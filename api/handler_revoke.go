@@ -35,6 +35,7 @@ func RevokeHandler(queries *database.Queries) http.HandlerFunc {
 
 		// Revoke the refresh token
 		if err := queries.RevokeRefreshToken(r.Context(), req.Token); err != nil {
+			logError(r, "failed to revoke token", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to revoke token"})
 			return
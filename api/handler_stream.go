@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/stream"
+)
+
+// How often a comment is sent to keep idle connections open
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamChirpsHandler handles GET /api/stream/chirps as Server-Sent Events
+func StreamChirpsHandler(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		filter := stream.Filter{TypePrefix: "chirp."}
+		if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+			authorID, err := uuid.Parse(authorIDStr)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid author_id"})
+				return
+			}
+			filter.AuthorID = authorID
+		}
+
+		var lastEventID int64
+		if idStr := r.Header.Get("Last-Event-ID"); idStr != "" {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid Last-Event-ID"})
+				return
+			}
+			lastEventID = id
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Streaming unsupported"})
+			return
+		}
+
+		// Streams outlive the server's read and write timeouts
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+
+		sub, backlog := hub.Subscribe(filter, lastEventID)
+		defer hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range backlog {
+			writeStreamEvent(w, ev)
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case ev, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind or shutdown; the client reconnects
+					return
+				}
+				writeStreamEvent(w, ev)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeStreamEvent writes a single SSE frame
+func writeStreamEvent(w http.ResponseWriter, ev stream.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,14 +11,30 @@ import (
 	"github.com/xaitan80/go-server/internal/database"
 )
 
-// Request struct for updating user
+// Request struct for updating user. Every field is optional and omitted
+// fields are left unchanged. An empty string clears a profile field, except
+// handle, which can be changed but not removed.
 type updateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password,omitempty"`
+	Email       string  `json:"email"`
+	Password    string  `json:"password,omitempty"`
+	Handle      *string `json:"handle,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	Location    *string `json:"location,omitempty"`
+	Website     *string `json:"website,omitempty"`
 }
 
+// hasProfile reports whether any profile field was provided
+func (req updateUserRequest) hasProfile() bool {
+	return req.Handle != nil || req.DisplayName != nil || req.Bio != nil ||
+		req.AvatarURL != nil || req.Location != nil || req.Website != nil
+}
+
+var errHandleTaken = errors.New("handle taken")
+
 // UpdateUserHandler handles PUT /api/users
-func UpdateUserHandler(queries *database.Queries, jwtSecret string) http.HandlerFunc {
+func UpdateUserHandler(db *sql.DB, jwtSecret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only allow PUT
 		if r.Method != http.MethodPut {
@@ -42,17 +59,24 @@ func UpdateUserHandler(queries *database.Queries, jwtSecret string) http.Handler
 			return
 		}
 
-		if req.Email == "" {
+		if req.Email == "" && req.Password == "" && !req.hasProfile() {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Nothing to update"})
+			return
+		}
+
+		if err := validateProfile(req); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Email is required"})
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 			return
 		}
 
-		// Prepare hashed password if provided
+		// Prepare hashed password if provided; NULL keeps the current one
 		var hashedPassword sql.NullString
 		if req.Password != "" {
 			hash, err := auth.HashPassword(req.Password)
 			if err != nil {
+				logError(r, "failed to hash password", err)
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to hash password"})
 				return
@@ -61,20 +85,46 @@ func UpdateUserHandler(queries *database.Queries, jwtSecret string) http.Handler
 				String: hash,
 				Valid:  true,
 			}
-		} else {
-			// Keep existing password by setting Valid = false
-			hashedPassword = sql.NullString{
-				Valid: false,
-			}
 		}
 
-		// Update user in database
-		updatedUser, err := queries.UpdateUser(r.Context(), database.UpdateUserParams{
-			ID:             userID,
-			Email:          req.Email,
-			HashedPassword: hashedPassword,
+		// Update account and profile together
+		var updatedUser database.User
+		err = database.RunInTx(r.Context(), db, func(q *database.Queries) error {
+			var err error
+			if req.Email != "" || req.Password != "" {
+				updatedUser, err = q.UpdateUser(r.Context(), database.UpdateUserParams{
+					ID:             userID,
+					Email:          sql.NullString{String: req.Email, Valid: req.Email != ""},
+					HashedPassword: hashedPassword,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if req.hasProfile() {
+				updatedUser, err = q.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+					ID:          userID,
+					Handle:      nullString(req.Handle),
+					DisplayName: nullString(req.DisplayName),
+					Bio:         nullString(req.Bio),
+					AvatarUrl:   nullString(req.AvatarURL),
+					Location:    nullString(req.Location),
+					Website:     nullString(req.Website),
+				})
+				if isUniqueViolation(err) {
+					return errHandleTaken
+				}
+			}
+			return err
 		})
 		if err != nil {
+			if errors.Is(err, errHandleTaken) {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Handle is already taken"})
+				return
+			}
+			logError(r, "failed to update user", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update user"})
 			return
@@ -82,15 +132,27 @@ func UpdateUserHandler(queries *database.Queries, jwtSecret string) http.Handler
 
 		// Respond with updated user (omit password)
 		resp := struct {
-			ID        string `json:"id"`
-			Email     string `json:"email"`
-			CreatedAt string `json:"created_at"`
-			UpdatedAt string `json:"updated_at"`
+			ID          string `json:"id"`
+			Email       string `json:"email"`
+			CreatedAt   string `json:"created_at"`
+			UpdatedAt   string `json:"updated_at"`
+			Handle      string `json:"handle,omitempty"`
+			DisplayName string `json:"display_name"`
+			Bio         string `json:"bio"`
+			AvatarURL   string `json:"avatar_url"`
+			Location    string `json:"location"`
+			Website     string `json:"website"`
 		}{
-			ID:        updatedUser.ID.String(),
-			Email:     updatedUser.Email,
-			CreatedAt: updatedUser.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: updatedUser.UpdatedAt.Format(time.RFC3339),
+			ID:          updatedUser.ID.String(),
+			Email:       updatedUser.Email,
+			CreatedAt:   updatedUser.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:   updatedUser.UpdatedAt.Format(time.RFC3339),
+			Handle:      updatedUser.Handle.String,
+			DisplayName: updatedUser.DisplayName,
+			Bio:         updatedUser.Bio,
+			AvatarURL:   updatedUser.AvatarUrl,
+			Location:    updatedUser.Location,
+			Website:     updatedUser.Website,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
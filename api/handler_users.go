@@ -50,6 +50,7 @@ func CreateUserHandler(queries *database.Queries) http.HandlerFunc {
 		// Hash the password
 		hash, err := auth.HashPassword(req.Password)
 		if err != nil {
+			logError(r, "failed to hash password", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to hash password"})
 			return
@@ -61,6 +62,7 @@ func CreateUserHandler(queries *database.Queries) http.HandlerFunc {
 			HashedPassword: sql.NullString{String: hash, Valid: true}, // ✅ fix here
 		})
 		if err != nil {
+			logError(r, "failed to create user", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create user"})
 			return
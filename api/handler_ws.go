@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/stream"
+	"github.com/xaitan80/go-server/internal/ws"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// WebSocketHandler handles GET /api/ws
+//
+// The access token is taken from the Authorization header or, for browsers
+// that cannot set headers on WebSocket requests, the "token" query parameter.
+func WebSocketHandler(hub *stream.Hub, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		tokenString, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			tokenString = r.URL.Query().Get("token")
+		}
+
+		userID, expiresAt, err := auth.ValidateJWTWithExpiry(tokenString, jwtSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or missing token"})
+			return
+		}
+
+		// Upgrade writes its own error response on failure
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		ws.NewSession(conn, hub, userID, expiresAt, jwtSecret).Run()
+	}
+}
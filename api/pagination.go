@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default and maximum page sizes for paginated endpoints
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor builds an opaque cursor pointing just past the given row
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reverses encodeCursor
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+
+	nanosStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
+
+// parseLimit reads the "limit" query parameter, clamped to maxPageSize
+func parseLimit(r *http.Request) (int32, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return 0, errors.New("invalid limit")
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return int32(limit), nil
+}
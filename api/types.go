@@ -1,6 +1,26 @@
 package api
 
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/xaitan80/go-server/internal/logging"
+)
+
 // ErrorResponse is a standard JSON error format for all API endpoints
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// logError logs the error behind a failed request with the request-scoped
+// logger, before the handler replies with a generic ErrorResponse
+func logError(r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context()).Error(msg, "error", err)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Chirp visibility levels stored in chirps.visibility. Followers-only chirps
+// are seen by the author's followers, mentioned-only chirps by the users
+// they mention; the author and mentioned users always see their chirps.
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityMentioned = "mentioned"
+)
+
+// optionalViewer returns the authenticated user for endpoints that also
+// serve anonymous requests. A missing Authorization header yields an invalid
+// NullUUID; a present but invalid token is an error.
+func optionalViewer(r *http.Request, jwtSecret string) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	userID, err := auth.GetUserIDFromHeader(r.Header, jwtSecret)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+// visibleChirp returns a published chirp if viewerID (which may be invalid
+// for anonymous requests) is allowed to see it, which also rules out chirps
+// across a block. Hidden chirps and chirps the viewer may not see report
+// sql.ErrNoRows so their existence isn't leaked.
+func visibleChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID, viewerID uuid.NullUUID) (database.Chirp, error) {
+	chirp, err := q.GetChirpByID(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.HiddenAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	if chirp.Visibility == chirpVisibilityPublic && !viewerID.Valid {
+		return chirp, nil
+	}
+
+	ok, err := q.CanViewChirp(ctx, database.CanViewChirpParams{
+		ID:       chirp.ID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
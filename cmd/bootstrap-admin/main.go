@@ -0,0 +1,88 @@
+// Command bootstrap-admin creates the first admin account, or promotes an
+// existing user, so the protected /admin endpoints can be reached.
+//
+//	go run ./cmd/bootstrap-admin -email admin@example.com
+//
+// The password is read from ADMIN_PASSWORD and is only needed when the user
+// doesn't exist yet. The command refuses to run once an admin exists unless
+// -force is given.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/authz"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the admin account")
+	force := flag.Bool("force", false, "run even if an admin already exists")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, relying on OS environment variables")
+	}
+
+	db, err := sql.Open("postgres", os.Getenv("DB_URL"))
+	if err != nil {
+		log.Fatalf("failed to open DB: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	err = database.RunInTx(ctx, db, func(q *database.Queries) error {
+		admins, err := q.CountUsersWithRole(ctx, authz.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins > 0 && !*force {
+			return errors.New("an admin already exists (use -force to add another)")
+		}
+
+		user, err := q.GetUserByEmail(ctx, *email)
+		if errors.Is(err, sql.ErrNoRows) {
+			password := os.Getenv("ADMIN_PASSWORD")
+			if password == "" {
+				return errors.New("user not found and ADMIN_PASSWORD is not set")
+			}
+			hashed, err := auth.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			user, err = q.CreateUser(ctx, database.CreateUserParams{
+				Email:          *email,
+				HashedPassword: sql.NullString{String: hashed, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			log.Printf("created user %s", user.ID)
+		} else if err != nil {
+			return err
+		}
+
+		_, err = q.SetUserRole(ctx, database.SetUserRoleParams{
+			ID:   user.ID,
+			Role: authz.RoleAdmin,
+		})
+		return err
+	})
+	if err != nil {
+		log.Fatalf("bootstrap failed: %v", err)
+	}
+
+	log.Printf("%s is now an admin", *email)
+}
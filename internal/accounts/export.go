@@ -0,0 +1,181 @@
+package accounts
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Export statuses stored in data_exports.status
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+type profileExport struct {
+	ID                  uuid.UUID  `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Email               string     `json:"email"`
+	IsChirpyRed         bool       `json:"is_chirpy_red"`
+	Handle              string     `json:"handle,omitempty"`
+	DisplayName         string     `json:"display_name"`
+	Bio                 string     `json:"bio"`
+	AvatarURL           string     `json:"avatar_url"`
+	Location            string     `json:"location"`
+	Website             string     `json:"website"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at"`
+}
+
+type chirpExport struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	ReplyToID   *uuid.UUID `json:"reply_to_id,omitempty"`
+	Status      string     `json:"status"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// Refresh tokens are exported without the token itself so the archive
+// can't be used to sign in
+type sessionExport struct {
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type billingExport struct {
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Details   json.RawMessage `json:"details"`
+}
+
+// archiveFile is one JSON document inside the export ZIP
+type archiveFile struct {
+	Name string
+	Data any
+}
+
+// BuildExport collects everything stored about a user and returns it as a
+// ZIP archive of JSON files
+func BuildExport(ctx context.Context, q *database.Queries, userID uuid.UUID) ([]byte, error) {
+	user, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	chirps, err := q.GetChirpsByAuthorID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	drafts, err := q.ListUnpublishedChirps(ctx, database.ListUnpublishedChirpsParams{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := q.ListUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	billing, err := q.ListUserBillingEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := profileExport{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		Location:    user.Location,
+		Website:     user.Website,
+	}
+	if user.DeletionRequestedAt.Valid {
+		profile.DeletionRequestedAt = &user.DeletionRequestedAt.Time
+	}
+
+	chirpsOut := make([]chirpExport, 0, len(chirps))
+	for _, c := range chirps {
+		chirpsOut = append(chirpsOut, chirpToExport(c))
+	}
+
+	draftsOut := make([]chirpExport, 0, len(drafts))
+	for _, c := range drafts {
+		draftsOut = append(draftsOut, chirpToExport(c))
+	}
+
+	sessionsOut := make([]sessionExport, 0, len(tokens))
+	for _, t := range tokens {
+		out := sessionExport{ExpiresAt: t.ExpiresAt}
+		if t.RevokedAt.Valid {
+			out.RevokedAt = &t.RevokedAt.Time
+		}
+		sessionsOut = append(sessionsOut, out)
+	}
+
+	billingOut := make([]billingExport, 0, len(billing))
+	for _, e := range billing {
+		billingOut = append(billingOut, billingExport{
+			Event:     e.Event,
+			CreatedAt: e.CreatedAt,
+			Details:   e.Details,
+		})
+	}
+
+	return writeArchive([]archiveFile{
+		{Name: "profile.json", Data: profile},
+		{Name: "chirps.json", Data: chirpsOut},
+		{Name: "drafts.json", Data: draftsOut},
+		{Name: "sessions.json", Data: sessionsOut},
+		{Name: "billing.json", Data: billingOut},
+	})
+}
+
+func chirpToExport(c database.Chirp) chirpExport {
+	out := chirpExport{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Body:      c.Body,
+		Status:    c.Status,
+	}
+	if c.ReplyToID.Valid {
+		out.ReplyToID = &c.ReplyToID.UUID
+	}
+	if c.ScheduledAt.Valid {
+		out.ScheduledAt = &c.ScheduledAt.Time
+	}
+	return out
+}
+
+// writeArchive encodes each file as indented JSON into a ZIP archive
+func writeArchive(files []archiveFile) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
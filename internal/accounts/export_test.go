@@ -0,0 +1,48 @@
+package accounts
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestWriteArchive(t *testing.T) {
+	data, err := writeArchive([]archiveFile{
+		{Name: "profile.json", Data: map[string]string{"email": "walt@example.com"}},
+		{Name: "chirps.json", Data: []chirpExport{}},
+	})
+	if err != nil {
+		t.Fatalf("writeArchive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Fatalf("got %d files, want 2", len(zr.File))
+	}
+
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("open %s: %v", zr.File[0].Name, err)
+	}
+	defer f.Close()
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", zr.File[0].Name, err)
+	}
+
+	var profile map[string]string
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		t.Fatalf("unmarshal profile: %v", err)
+	}
+	if profile["email"] != "walt@example.com" {
+		t.Errorf("email = %q, want walt@example.com", profile["email"])
+	}
+	if zr.File[1].Name != "chirps.json" {
+		t.Errorf("second file = %q, want chirps.json", zr.File[1].Name)
+	}
+}
@@ -0,0 +1,84 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+const (
+	defaultExportPollInterval = 5 * time.Second
+	// An export claimed longer ago than this is assumed lost and built again
+	exportLease = 10 * time.Minute
+)
+
+// Exporter builds pending data exports in the background. Exports are
+// claimed with FOR UPDATE SKIP LOCKED, so every server instance can run one.
+type Exporter struct {
+	q            *database.Queries
+	pollInterval time.Duration
+}
+
+// NewExporter creates an exporter that builds exports with q
+func NewExporter(q *database.Queries) *Exporter {
+	return &Exporter{
+		q:            q,
+		pollInterval: defaultExportPollInterval,
+	}
+}
+
+// Run builds pending exports until ctx is cancelled
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			export, err := e.q.ClaimDataExport(ctx, exportLease.Seconds())
+			if err != nil {
+				if !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, context.Canceled) {
+					log.Printf("accounts: failed to claim export: %v", err)
+				}
+				break
+			}
+			RunExport(ctx, e.q, export)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunExport builds the archive for a claimed export and stores the result.
+// An export interrupted by ctx being cancelled stays pending, so it is built
+// again once its claim lapses.
+func RunExport(ctx context.Context, q *database.Queries, export database.DataExport) {
+	archive, err := BuildExport(ctx, q, export.UserID)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("accounts: export %s failed: %v", export.ID, err)
+		if err := q.FailDataExport(ctx, database.FailDataExportParams{
+			ID:    export.ID,
+			Error: sql.NullString{String: err.Error(), Valid: true},
+		}); err != nil {
+			log.Printf("accounts: failed to record export %s failure: %v", export.ID, err)
+		}
+		return
+	}
+
+	if err := q.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:      export.ID,
+		Archive: archive,
+	}); err != nil {
+		log.Printf("accounts: failed to store export %s: %v", export.ID, err)
+	}
+}
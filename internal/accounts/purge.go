@@ -0,0 +1,94 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// Purger hard-deletes accounts whose grace period has expired. Rows owned
+// by the user are removed by ON DELETE CASCADE, and their uploads are
+// deleted from the blob store afterwards.
+type Purger struct {
+	db       *sql.DB
+	blobs    media.BlobStore
+	grace    time.Duration
+	interval time.Duration
+}
+
+// NewPurger creates a purger for accounts deleted more than grace ago
+func NewPurger(db *sql.DB, blobs media.BlobStore, grace time.Duration) *Purger {
+	return &Purger{
+		db:       db,
+		blobs:    blobs,
+		grace:    grace,
+		interval: defaultPurgeInterval,
+	}
+}
+
+// Run purges expired accounts until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("accounts: purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("accounts: purged %d deleted accounts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce deletes every expired account and records a user.deleted
+// event for each. Outbox events carrying the user's data are deleted with
+// the account, so only the user.deleted event still references them. It
+// returns the number of accounts removed. Uploads are
+// deleted once the rows are committed; one that fails to delete is logged
+// and left behind.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	var purged []database.PurgeDeletedUsersRow
+
+	err := database.RunInTx(ctx, p.db, func(q *database.Queries) error {
+		var err error
+		purged, err = q.PurgeDeletedUsers(ctx, time.Now().Add(-p.grace))
+		if err != nil {
+			return err
+		}
+
+		for _, u := range purged {
+			if _, err := q.DeleteUserOutboxEvents(ctx, u.ID); err != nil {
+				return err
+			}
+			if err := outbox.Enqueue(ctx, q, outbox.TopicUserDeleted, u.ID, struct {
+				UserID string `json:"user_id"`
+			}{UserID: u.ID.String()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, u := range purged {
+		if err := media.DeleteAll(ctx, p.blobs, u.BlobKeys); err != nil {
+			log.Printf("accounts: failed to delete uploads of user %s: %v", u.ID, err)
+		}
+	}
+	return len(purged), nil
+}
@@ -0,0 +1,103 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+// TestPurgeOnceDeletesOutboxEvents needs a migrated database in TEST_DB_URL
+func TestPurgeOnceDeletesOutboxEvents(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	q := database.New(db)
+
+	user, err := q.CreateUser(ctx, database.CreateUserParams{
+		Email:          uuid.NewString() + "@example.com",
+		HashedPassword: sql.NullString{String: "unused", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := q.RequestUserDeletion(ctx, user.ID); err != nil {
+		t.Fatalf("RequestUserDeletion: %v", err)
+	}
+
+	// One event of each shape that carries the user's data, plus one that
+	// doesn't and must survive
+	otherID := uuid.New()
+	events := []struct {
+		topic       string
+		aggregateID uuid.UUID
+		payload     map[string]string
+	}{
+		{outbox.TopicUserUpgraded, user.ID, map[string]string{"user_id": user.ID.String()}},
+		{outbox.TopicChirpCreated, uuid.New(), map[string]string{"user_id": user.ID.String(), "body": "hello"}},
+		{outbox.TopicNotificationCreated, uuid.New(), map[string]string{"user_id": otherID.String(), "actor_id": user.ID.String()}},
+		{outbox.TopicChirpCreated, uuid.New(), map[string]string{"user_id": otherID.String(), "body": "unrelated"}},
+	}
+	for _, e := range events {
+		if err := outbox.Enqueue(ctx, q, e.topic, e.aggregateID, e.payload); err != nil {
+			t.Fatalf("Enqueue %s: %v", e.topic, err)
+		}
+	}
+	defer db.Exec(`DELETE FROM outbox_events WHERE aggregate_id = $1 OR payload->>'user_id' = $2`, user.ID, otherID.String())
+
+	blobs, err := media.NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	purger := NewPurger(db, blobs, -time.Minute)
+	if _, err := purger.PurgeOnce(ctx); err != nil {
+		t.Fatalf("PurgeOnce: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT topic FROM outbox_events
+		WHERE aggregate_id = $1
+		   OR payload->>'user_id' = $1::text
+		   OR payload->>'actor_id' = $1::text`, user.ID)
+	if err != nil {
+		t.Fatalf("query outbox: %v", err)
+	}
+	defer rows.Close()
+	var topics []string
+	for rows.Next() {
+		var topic string
+		if err := rows.Scan(&topic); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		topics = append(topics, topic)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	if len(topics) != 1 || topics[0] != outbox.TopicUserDeleted {
+		t.Errorf("events referencing the user after purge = %v, want only %s", topics, outbox.TopicUserDeleted)
+	}
+
+	var unrelated int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox_events WHERE payload->>'user_id' = $1`, otherID.String()).Scan(&unrelated); err != nil {
+		t.Fatalf("count unrelated: %v", err)
+	}
+	if unrelated != 1 {
+		t.Errorf("unrelated events after purge = %d, want 1", unrelated)
+	}
+}
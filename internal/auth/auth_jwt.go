@@ -42,6 +42,13 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 
 // ValidateJWT validates the JWT and returns the user ID stored in the Subject field.
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	id, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return id, err
+}
+
+// ValidateJWTWithExpiry validates the JWT like ValidateJWT and also returns
+// the time at which the token expires (zero if it has no expiry).
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -49,20 +56,25 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 		return []byte(tokenSecret), nil
 	})
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 
 	claims, ok := token.Claims.(*jwt.RegisteredClaims)
 	if !ok || !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return uuid.Nil, time.Time{}, errors.New("invalid token")
 	}
-
 	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
+	}
+
+	// Tokens without an exp claim never expire
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
 	}
 
-	return id, nil
+	return id, expiresAt, nil
 }
 
 // GetUserIDFromHeader extracts the user ID from the Authorization header JWT.
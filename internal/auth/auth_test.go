@@ -86,3 +86,26 @@ func TestWrongSecretJWT(t *testing.T) {
 		t.Fatal("expected error validating token with wrong secret, got nil")
 	}
 }
+
+func TestValidateJWTWithExpiry(t *testing.T) {
+	secret := "supersecret"
+	userID := uuid.New()
+	exp := time.Minute * 5
+
+	before := time.Now().Add(exp).Add(-time.Second)
+	token, err := MakeJWT(userID, secret, exp)
+	if err != nil {
+		t.Fatalf("failed to create JWT: %v", err)
+	}
+
+	id, expiresAt, err := ValidateJWTWithExpiry(token, secret)
+	if err != nil {
+		t.Fatalf("failed to validate JWT: %v", err)
+	}
+	if id != userID {
+		t.Errorf("expected userID %v, got %v", userID, id)
+	}
+	if expiresAt.Before(before) || expiresAt.After(time.Now().Add(exp)) {
+		t.Errorf("unexpected expiry %v", expiresAt)
+	}
+}
@@ -0,0 +1,55 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Roles stored in users.role
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists every role a user can be assigned
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// IsValidRole reports whether r is a known role
+func IsValidRole(r string) bool {
+	for _, known := range Roles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions granted to roles through the role_permissions table
+const (
+	PermMetricsRead   = "metrics:read"
+	PermPlatformReset = "platform:reset"
+	PermRolesManage   = "roles:manage"
+	PermReportsReview = "reports:review"
+	PermUsersSuspend  = "users:suspend"
+)
+
+// Policy answers permission checks against the roles stored in Postgres
+type Policy struct {
+	queries *database.Queries
+}
+
+// NewPolicy creates a policy backed by the given queries
+func NewPolicy(queries *database.Queries) *Policy {
+	return &Policy{queries: queries}
+}
+
+// Can reports whether the user's role grants perm
+func (p *Policy) Can(ctx context.Context, userID uuid.UUID, perm string) (bool, error) {
+	return p.queries.UserHasPermission(ctx, database.UserHasPermissionParams{
+		UserID:     userID,
+		Permission: perm,
+	})
+}
@@ -0,0 +1,128 @@
+// Package certs serves TLS certificates that can be replaced on disk while
+// the server runs, and loads the CA pool for client certificates.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader holds the current certificate for a cert/key file pair. Reloads
+// only affect new handshakes, so open connections are not dropped.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader loads the certificate and key, failing if they can't be used
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; use it as
+// tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload reads the files again. On error the previous certificate stays in
+// use.
+func (r *Reloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// Watch reloads the certificate when either file changes, checking every
+// interval, and whenever a value arrives on reload (e.g. SIGHUP). It runs
+// until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			r.reloadAndLog("signal")
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				slog.Error("certs: failed to check certificate files", "error", err)
+				continue
+			}
+			r.mu.RLock()
+			changed := !modTime.Equal(r.modTime)
+			r.mu.RUnlock()
+			if changed {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog(trigger string) {
+	if err := r.Reload(); err != nil {
+		slog.Error("certs: reload failed, keeping the current certificate", "trigger", trigger, "error", err)
+		return
+	}
+	slog.Info("certs: certificate reloaded", "trigger", trigger)
+}
+
+// latestModTime returns the newer modification time of the two files
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// LoadCAPool reads PEM-encoded CA certificates for verifying client
+// certificates
+func LoadCAPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in " + path)
+	}
+	return pool, nil
+}
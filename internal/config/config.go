@@ -1,8 +1,188 @@
+// Package config loads the server configuration from defaults, an optional
+// YAML or TOML file, a .env file and the environment, and validates it.
 package config
 
-// APIConfig holds global configuration values for the API handlers
-type APIConfig struct {
-	JWTSecret string
-	PolkaKey  string
-	Platform  string
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Config is the complete server configuration. Every field can be set in
+// the config file under its key tag and overridden by its env variable.
+// Fields tagged secret are redacted when the config is printed or logged.
+type Config struct {
+	Port            string        `key:"port" env:"PORT"`
+	Platform        string        `key:"platform" env:"PLATFORM"`
+	LogLevel        string        `key:"log_level" env:"LOG_LEVEL"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// H2C serves HTTP/2 without TLS, for use behind a plaintext HTTP/2 proxy
+	H2C bool `key:"h2c" env:"H2C"`
+
+	TLS       TLSConfig       `key:"tls"`
+	DB        DBConfig        `key:"db"`
+	Auth      AuthConfig      `key:"auth"`
+	Chirps    ChirpsConfig    `key:"chirps"`
+	CORS      CORSConfig      `key:"cors"`
+	RateLimit RateLimitConfig `key:"rate_limit"`
+	Outbox    OutboxConfig    `key:"outbox"`
+	Media     MediaConfig     `key:"media"`
+	Metrics   MetricsConfig   `key:"metrics"`
+	Tracing   TracingConfig   `key:"tracing"`
+}
+
+// TLSConfig enables TLS when both CertFile and KeyFile are set. The files
+// are reloaded when they change. With ClientCAFile, /admin routes require a
+// client certificate signed by one of its CAs.
+type TLSConfig struct {
+	CertFile       string        `key:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile        string        `key:"key_file" env:"TLS_KEY_FILE"`
+	ClientCAFile   string        `key:"client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	ReloadInterval time.Duration `key:"reload_interval" env:"TLS_RELOAD_INTERVAL"`
+}
+
+// Enabled reports whether the server listens with TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// DBConfig configures the Postgres connection pool
+type DBConfig struct {
+	URL             string        `key:"url" env:"DB_URL" secret:"true"`
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+// AuthConfig holds signing keys and token lifetimes
+type AuthConfig struct {
+	JWTSecret string `key:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	PolkaKey  string `key:"polka_key" env:"POLKA_KEY" secret:"true"`
+	// AccessTokenTTL applies to tokens issued at login,
+	// RefreshedAccessTokenTTL to those issued by /api/refresh
+	AccessTokenTTL          time.Duration `key:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshedAccessTokenTTL time.Duration `key:"refreshed_access_token_ttl" env:"REFRESHED_ACCESS_TOKEN_TTL"`
+	AccountDeletionGrace    time.Duration `key:"account_deletion_grace" env:"ACCOUNT_DELETION_GRACE"`
+	// Cookies also hands out the access token in an HttpOnly session
+	// cookie at login, guarded by a CSRF token
+	Cookies bool `key:"cookies" env:"AUTH_COOKIES"`
+}
+
+// ChirpsConfig limits chirps
+type ChirpsConfig struct {
+	MaxLength int           `key:"max_length" env:"CHIRP_MAX_LENGTH"`
+	Retention time.Duration `key:"retention" env:"CHIRP_RETENTION"`
+}
+
+// CORSConfig lists the origins allowed to call the API from browsers.
+// AllowCredentials lets them send session cookies.
+type CORSConfig struct {
+	AllowedOrigins   []string      `key:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `key:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// RateLimitConfig selects where rate limit buckets are kept ("memory",
+// "postgres" to share them between instances, or "off") and which proxies
+// are trusted to set X-Forwarded-For
+type RateLimitConfig struct {
+	Store          string   `key:"store" env:"RATE_LIMIT_STORE"`
+	TrustedProxies []string `key:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// TrustedProxyPrefixes parses TrustedProxies, given as CIDR ranges or single
+// IPs
+func (c RateLimitConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, v := range c.TrustedProxies {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy range %q", v)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q", v)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// OutboxConfig names the optional external outbox sinks
+type OutboxConfig struct {
+	WebhookURL string `key:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`
+	NATSURL    string `key:"nats_url" env:"OUTBOX_NATS_URL"`
+}
+
+// MediaConfig selects local or S3-compatible media storage
+type MediaConfig struct {
+	Dir         string `key:"dir" env:"MEDIA_DIR"`
+	S3Endpoint  string `key:"s3_endpoint" env:"MEDIA_S3_ENDPOINT"`
+	S3Bucket    string `key:"s3_bucket" env:"MEDIA_S3_BUCKET"`
+	S3Region    string `key:"s3_region" env:"MEDIA_S3_REGION"`
+	S3AccessKey string `key:"s3_access_key" env:"MEDIA_S3_ACCESS_KEY"`
+	S3SecretKey string `key:"s3_secret_key" env:"MEDIA_S3_SECRET_KEY" secret:"true"`
+	PublicURL   string `key:"public_url" env:"MEDIA_PUBLIC_URL"`
+}
+
+// MetricsConfig moves the Prometheus endpoint to its own listener at Addr,
+// meant to be reachable only by the scraper. Without it /metrics is served
+// on the main port to admins only.
+type MetricsConfig struct {
+	Addr string `key:"addr" env:"METRICS_ADDR"`
+}
+
+// TracingConfig selects the trace exporter
+type TracingConfig struct {
+	Exporter string `key:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+// Default returns the configuration used for anything not set elsewhere
+func Default() Config {
+	return Config{
+		Port:            "8080",
+		LogLevel:        "info",
+		ShutdownTimeout: 30 * time.Second,
+		TLS: TLSConfig{
+			ReloadInterval: 30 * time.Second,
+		},
+		DB: DBConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:          24 * time.Hour,
+			RefreshedAccessTokenTTL: time.Hour,
+			// Deleted accounts can be recovered by logging in for 30 days
+			AccountDeletionGrace: 30 * 24 * time.Hour,
+		},
+		Chirps: ChirpsConfig{
+			MaxLength: 140,
+			// Soft-deleted chirps are kept 30 days before they are purged
+			Retention: 30 * 24 * time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			MaxAge:         10 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Store: "memory",
+		},
+		Media: MediaConfig{
+			Dir: "uploads",
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+	}
 }
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileThenEnvironment(t *testing.T) {
+	for name, content := range map[string]string{
+		"chirpy.yaml": `
+port: 9000
+db:
+  url: postgres://file
+  max_open_conns: 50
+auth:
+  jwt_secret: from-file
+  access_token_ttl: 2h
+cors:
+  allowed_origins: [https://a.example, https://b.example]
+`,
+		"chirpy.toml": `
+port = 9000
+[db]
+url = "postgres://file"
+max_open_conns = 50
+[auth]
+jwt_secret = "from-file"
+access_token_ttl = "2h"
+[cors]
+allowed_origins = ["https://a.example", "https://b.example"]
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(FileEnv, writeFile(t, name, content))
+			t.Setenv("JWT_SECRET", "from-env")
+			t.Setenv("DB_URL", "")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != "9000" || cfg.DB.URL != "postgres://file" || cfg.DB.MaxOpenConns != 50 {
+				t.Errorf("file values not applied: %+v", cfg)
+			}
+			if cfg.Auth.JWTSecret != "from-env" {
+				t.Errorf("environment should override the file, got %q", cfg.Auth.JWTSecret)
+			}
+			if cfg.Auth.AccessTokenTTL != 2*time.Hour {
+				t.Errorf("AccessTokenTTL = %s", cfg.Auth.AccessTokenTTL)
+			}
+			if cfg.Chirps.MaxLength != 140 {
+				t.Errorf("unset values should keep their default, got %d", cfg.Chirps.MaxLength)
+			}
+			if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example" {
+				t.Errorf("AllowedOrigins = %v", cfg.CORS.AllowedOrigins)
+			}
+		})
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	t.Setenv(FileEnv, writeFile(t, "chirpy.yaml", "chirps:\n  max_lenght: 200\n"))
+	t.Setenv("DB_URL", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("ACCESS_TOKEN_TTL", "soon")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`unknown key "chirps.max_lenght"`, "ACCESS_TOKEN_TTL", `invalid duration "soon"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	t.Setenv(FileEnv, "")
+	t.Setenv("ACCESS_TOKEN_TTL", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://ok.example,https://bad.example/path")
+	_, err = Load()
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{"DB_URL is required", "JWT_SECRET is required", "bad.example/path"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.DB.URL = "postgres://user:hunter2@db/chirpy"
+	cfg.Auth.JWTSecret = "super-secret"
+
+	out := cfg.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "super-secret") {
+		t.Fatalf("secrets leaked:\n%s", out)
+	}
+	for _, want := range []string{"db.url=[REDACTED]", "auth.jwt_secret=[REDACTED]", "auth.polka_key=\n", "port=8080"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	cfg := RateLimitConfig{TrustedProxies: []string{"10.1.2.3/8", "192.0.2.10", "::ffff:198.51.100.7"}}
+	prefixes, err := cfg.TrustedProxyPrefixes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range prefixes {
+		got = append(got, p.String())
+	}
+	if want := "10.0.0.0/8 192.0.2.10/32 198.51.100.7/32"; strings.Join(got, " ") != want {
+		t.Errorf("prefixes = %v, want %s", got, want)
+	}
+
+	cfg.TrustedProxies = []string{"not-an-ip"}
+	if _, err := cfg.TrustedProxyPrefixes(); err == nil {
+		t.Error("invalid proxy address accepted")
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// FileEnv names the environment variable pointing at the config file
+const FileEnv = "CONFIG_FILE"
+
+// Load builds the configuration. Later sources win:
+//
+//  1. Default()
+//  2. the YAML (.yaml, .yml) or TOML (.toml) file named by CONFIG_FILE
+//  3. the .env file in the working directory, if any
+//  4. the process environment
+//
+// Variables already in the environment are not overridden by .env, which is
+// read first so it can also set CONFIG_FILE. All parse and validation errors
+// are returned together.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	cfg := Default()
+	var errs []error
+
+	if path := os.Getenv(FileEnv); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, cfg.apply(func(f field) (string, bool) {
+			v, ok := values[f.key]
+			delete(values, f.key)
+			return v, ok
+		})...)
+		for _, key := range sortedKeys(values) {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", path, key))
+		}
+	}
+
+	errs = append(errs, cfg.apply(func(f field) (string, bool) {
+		v := os.Getenv(f.env)
+		return v, v != ""
+	})...)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// field is one setting of Config, addressed by its dotted file key
+type field struct {
+	key    string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists the settings of cfg in declaration order
+func (cfg *Config) fields() []field {
+	var out []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			key := prefix + sf.Tag.Get("key")
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			out = append(out, field{
+				key:    key,
+				env:    sf.Tag.Get("env"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// apply sets every field that lookup has a value for
+func (cfg *Config) apply(lookup func(field) (string, bool)) []error {
+	var errs []error
+	for _, f := range cfg.fields() {
+		raw, ok := lookup(f)
+		if !ok {
+			continue
+		}
+		if err := f.set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", f.env, f.key, err))
+		}
+	}
+	return errs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into the field according to its type. Lists are comma
+// separated.
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(raw)
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
+
+// readFile decodes a YAML or TOML file into dotted keys with string values
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(values, "", doc)
+	return values, nil
+}
+
+// flatten turns nested tables into dotted keys; lists are joined with commas
+func flatten(out map[string]string, prefix string, doc map[string]any) {
+	for k, v := range doc {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(out, key+".", v)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks the configuration and reports every problem at once
+func (cfg *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		fail("PORT must be a number between 1 and 65535, got %q", cfg.Port)
+	}
+	switch cfg.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("LOG_LEVEL must be debug, info, warn or error, got %q", cfg.LogLevel)
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLS.ClientCAFile != "" && !cfg.TLS.Enabled() {
+		fail("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if cfg.H2C && cfg.TLS.Enabled() {
+		fail("H2C is for plaintext listeners and can't be combined with TLS")
+	}
+
+	if cfg.DB.URL == "" {
+		fail("DB_URL is required")
+	}
+	if cfg.DB.MaxOpenConns < 0 || cfg.DB.MaxIdleConns < 0 {
+		fail("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS can't be negative")
+	}
+	if cfg.DB.MaxOpenConns > 0 && cfg.DB.MaxIdleConns > cfg.DB.MaxOpenConns {
+		fail("DB_MAX_IDLE_CONNS (%d) can't exceed DB_MAX_OPEN_CONNS (%d)", cfg.DB.MaxIdleConns, cfg.DB.MaxOpenConns)
+	}
+
+	if cfg.Auth.JWTSecret == "" {
+		fail("JWT_SECRET is required")
+	}
+	if cfg.Chirps.MaxLength < 1 {
+		fail("CHIRP_MAX_LENGTH must be positive, got %d", cfg.Chirps.MaxLength)
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout},
+		{"TLS_RELOAD_INTERVAL", cfg.TLS.ReloadInterval},
+		{"ACCESS_TOKEN_TTL", cfg.Auth.AccessTokenTTL},
+		{"REFRESHED_ACCESS_TOKEN_TTL", cfg.Auth.RefreshedAccessTokenTTL},
+		{"ACCOUNT_DELETION_GRACE", cfg.Auth.AccountDeletionGrace},
+		{"CHIRP_RETENTION", cfg.Chirps.Retention},
+	} {
+		if d.value <= 0 {
+			fail("%s must be positive, got %s", d.name, d.value)
+		}
+	}
+	if cfg.DB.ConnMaxLifetime < 0 || cfg.DB.ConnMaxIdleTime < 0 || cfg.CORS.MaxAge < 0 {
+		fail("DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME and CORS_MAX_AGE can't be negative")
+	}
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		if origin == "*" && cfg.CORS.AllowCredentials {
+			fail("CORS_ALLOWED_ORIGINS can't be * with CORS_ALLOW_CREDENTIALS; list the origins")
+		} else if origin != "*" && !validOrigin(origin) {
+			fail("CORS_ALLOWED_ORIGINS: %q is not an origin like https://example.com", origin)
+		}
+	}
+	for _, method := range cfg.CORS.AllowedMethods {
+		if strings.ToUpper(method) != method || strings.ContainsAny(method, " \t,") {
+			fail("CORS_ALLOWED_METHODS: %q is not an HTTP method like GET", method)
+		}
+	}
+
+	switch cfg.RateLimit.Store {
+	case "memory", "postgres", "off":
+	default:
+		fail("RATE_LIMIT_STORE must be memory, postgres or off, got %q", cfg.RateLimit.Store)
+	}
+	if _, err := cfg.RateLimit.TrustedProxyPrefixes(); err != nil {
+		fail("TRUSTED_PROXIES: %v", err)
+	}
+
+	if cfg.Outbox.WebhookURL != "" && !validHTTPURL(cfg.Outbox.WebhookURL) {
+		fail("OUTBOX_WEBHOOK_URL must be an http(s) URL")
+	}
+	if cfg.Media.S3Endpoint != "" {
+		if !validHTTPURL(cfg.Media.S3Endpoint) {
+			fail("MEDIA_S3_ENDPOINT must be an http(s) URL")
+		}
+		if cfg.Media.S3Bucket == "" || cfg.Media.S3AccessKey == "" || cfg.Media.S3SecretKey == "" {
+			fail("MEDIA_S3_BUCKET, MEDIA_S3_ACCESS_KEY and MEDIA_S3_SECRET_KEY are required with MEDIA_S3_ENDPOINT")
+		}
+	}
+
+	if cfg.Metrics.Addr != "" {
+		if _, port, err := net.SplitHostPort(cfg.Metrics.Addr); err != nil || port == "" {
+			fail("METRICS_ADDR must be host:port or :port, got %q", cfg.Metrics.Addr)
+		}
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "", "none", "otlp", "stdout":
+	default:
+		fail("OTEL_TRACES_EXPORTER must be none, otlp or stdout, got %q", cfg.Tracing.Exporter)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validOrigin accepts scheme://host[:port] with nothing after it
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+func validHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+const redacted = "[REDACTED]"
+
+// String prints one key=value line per setting with secrets redacted
+func (cfg *Config) String() string {
+	var b strings.Builder
+	for _, f := range cfg.fields() {
+		fmt.Fprintf(&b, "%s=%s\n", f.key, f.display())
+	}
+	return b.String()
+}
+
+// LogValue logs the configuration as a group with secrets redacted
+func (cfg *Config) LogValue() slog.Value {
+	fields := cfg.fields()
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.String(f.key, f.display())
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// display formats the value for printing, hiding set secrets
+func (f field) display() string {
+	if f.secret && !f.value.IsZero() {
+		return redacted
+	}
+	switch v := f.value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
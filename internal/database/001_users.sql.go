@@ -16,7 +16,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, website, deletion_requested_at, role, suspended_until
 `
 
 type CreateUserParams struct {
@@ -34,6 +34,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+		&i.DeletionRequestedAt,
+		&i.Role,
+		&i.SuspendedUntil,
 	)
 	return i, err
 }
@@ -48,7 +57,7 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, website, deletion_requested_at, role, suspended_until
 FROM users
 WHERE email = $1
 `
@@ -63,6 +72,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+		&i.DeletionRequestedAt,
+		&i.Role,
+		&i.SuspendedUntil,
 	)
 	return i, err
 }
@@ -109,14 +127,18 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Ge
 	return i, err
 }
 
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :exec
+const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :execrows
 UPDATE users
 SET is_chirpy_red = TRUE,
     updated_at = NOW()
 WHERE id = $1
+  AND NOT is_chirpy_red
 `
 
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, id)
-	return err
+func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -6,18 +6,206 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type BillingEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Event     string
+	Details   json.RawMessage
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Body            string
+	UserID          uuid.UUID
+	AuthorID        uuid.UUID
+	ReplyToID       uuid.NullUUID
+	HiddenAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	DeletedBy       uuid.NullUUID
+	Status          string
+	ScheduledAt     sql.NullTime
+	PinnedAt        sql.NullTime
+	Visibility      string
+	PublishAttempts int32
+	PublishError    sql.NullString
+	PublishRetryAt  sql.NullTime
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type ChirpReport struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ChirpID    uuid.UUID
+	ReporterID uuid.UUID
+	Reason     string
+	Details    string
+	ResolvedAt sql.NullTime
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	CreatedBy uuid.UUID
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+	LeftAt         sql.NullTime
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	Archive     []byte
+	Error       sql.NullString
+	CreatedAt   time.Time
+	CompletedAt sql.NullTime
+	StartedAt   sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	IsPrivate   bool
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ListSubscription struct {
+	ListID    uuid.UUID
 	UserID    uuid.UUID
-	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	StorageKey   string
+	Url          string
+	ThumbnailKey string
+	ThumbnailUrl string
+	ChirpID      uuid.NullUUID
+	Position     sql.NullInt32
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type ModerationAction struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ModeratorID    uuid.NullUUID
+	Action         string
+	ChirpID        uuid.NullUUID
+	ChirpBody      string
+	TargetUserID   uuid.UUID
+	Reason         string
+	SuspendedUntil sql.NullTime
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Type      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID
+	Type    string
+	Enabled bool
+}
+
+type OutboxEvent struct {
+	ID          int64
+	CreatedAt   time.Time
+	Topic       string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	Attempts    int32
+	LastError   sql.NullString
+	AvailableAt time.Time
+	PublishedAt sql.NullTime
+	Traceparent string
+}
+
+type Poll struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ChirpID     uuid.UUID
+	ClosesAt    time.Time
+	HideResults bool
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	PollID   uuid.UUID
+	Position int32
+	Label    string
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
+	OptionID  uuid.UUID
+	UserID    uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -27,11 +215,42 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Role struct {
+	Name        string
+	Description string
+}
+
+type RolePermission struct {
+	Role       string
+	Permission string
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      sql.NullString
+	IsChirpyRed         bool
+	Handle              sql.NullString
+	DisplayName         string
+	Bio                 string
+	AvatarUrl           string
+	Location            string
+	Website             string
+	DeletionRequestedAt sql.NullTime
+	Role                string
+	SuspendedUntil      sql.NullTime
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
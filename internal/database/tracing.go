@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/xaitan80/go-server/internal/database")
+
+// Traced wraps db so every query runs in a child span of the span in the
+// query's context, named after the sqlc query. Queries made outside a trace,
+// such as background worker polls, are not traced.
+func Traced(db DBTX) DBTX {
+	return tracedDB{db: db}
+}
+
+type tracedDB struct {
+	db DBTX
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	res, err := t.db.ExecContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return res, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	stmt, err := t.db.PrepareContext(ctx, query)
+	recordQueryError(span, err)
+	return stmt, err
+}
+
+// QueryContext spans cover running the query, not iterating the rows
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return rows, err
+}
+
+// QueryRowContext errors only surface on Scan, so they aren't recorded
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		// The span from an untraced context is a no-op
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	name := queryName(query)
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBQuerySummary(name),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// recordQueryError marks the span as failed; a missing row is a normal
+// outcome and is not treated as an error
+func recordQueryError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// queryName returns the sqlc query name from the "-- name: X :kind" header
+// of generated queries, or "query" for anything else
+func queryName(query string) string {
+	header, _, _ := strings.Cut(query, "\n")
+	fields := strings.Fields(header)
+	if len(fields) >= 3 && fields[0] == "--" && fields[1] == "name:" {
+		return fields[2]
+	}
+	return "query"
+}
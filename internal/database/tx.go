@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RunInTx runs fn with a Queries bound to a new transaction on db.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// Queries in the transaction are traced like those outside it.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(q *Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(New(Traced(tx))); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
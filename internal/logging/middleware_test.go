@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareAssignsRequestIDAndLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info")
+
+	var ctxLogged bool
+	h := Middleware(logger, func(*http.Request) string { return "user-1" }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("inside")
+		ctxLogged = true
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chirps", nil))
+
+	id := rec.Header().Get(RequestIDHeader)
+	if id == "" {
+		t.Fatal("expected a generated request ID")
+	}
+	if !ctxLogged {
+		t.Fatal("handler did not run")
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(lines[1], &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"msg":        "request",
+		"request_id": id,
+		"method":     "GET",
+		"path":       "/api/chirps",
+		"status":     float64(http.StatusTeapot),
+		"bytes":      float64(5),
+		"user_id":    "user-1",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, entry[k])
+		}
+	}
+	if _, ok := entry["latency_ms"]; !ok {
+		t.Error("missing latency_ms")
+	}
+}
+
+func TestMiddlewarePropagatesRequestID(t *testing.T) {
+	h := Middleware(New(&bytes.Buffer{}, "info"), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("expected propagated ID, got %q", got)
+	}
+
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got == "" || got == "bad id\nwith newline" {
+		t.Errorf("expected a fresh ID for invalid input, got %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Upload limits
+const (
+	MaxUploadBytes = 5 << 20
+	// Guards against decompression bombs that are small on disk
+	maxPixels = 40_000_000
+	// Thumbnails fit inside a thumbnailSize square
+	thumbnailSize = 320
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// Extensions for the accepted content types
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image is a validated upload and its generated thumbnail
+type Image struct {
+	ContentType   string
+	Extension     string
+	Width         int
+	Height        int
+	Thumbnail     []byte
+	ThumbnailType string
+}
+
+// ProcessImage checks that data is an accepted image, judging by its
+// content rather than any client-supplied header, and builds a thumbnail
+func ProcessImage(data []byte) (Image, error) {
+	if len(data) > MaxUploadBytes {
+		return Image{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	cfg, err := decodeConfig(contentType, data)
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return Image{}, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return Image{}, ErrTooLarge
+	}
+
+	src, err := decode(contentType, data)
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+	thumb, thumbType, err := thumbnail(src, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType:   contentType,
+		Extension:     ext,
+		Width:         cfg.Width,
+		Height:        cfg.Height,
+		Thumbnail:     thumb,
+		ThumbnailType: thumbType,
+	}, nil
+}
+
+func decodeConfig(contentType string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.DecodeConfig(r)
+	case "image/png":
+		return png.DecodeConfig(r)
+	case "image/gif":
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+func decode(contentType string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Decode(r)
+	case "image/png":
+		return png.Decode(r)
+	case "image/gif":
+		// Only the first frame of an animation is used
+		return gif.Decode(r)
+	default:
+		return webp.Decode(r)
+	}
+}
+
+// thumbnail scales src to fit thumbnailSize, keeping the aspect ratio and
+// never upscaling. PNG and GIF sources keep transparency as PNG; the rest
+// become JPEG.
+func thumbnail(src image.Image, contentType string) ([]byte, string, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			h = max(1, h*thumbnailSize/w)
+			w = thumbnailSize
+		} else {
+			w = max(1, w*thumbnailSize/h)
+			h = thumbnailSize
+		}
+	}
+
+	var buf bytes.Buffer
+	if contentType == "image/png" || contentType == "image/gif" {
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
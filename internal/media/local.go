@@ -0,0 +1,94 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs on the local filesystem. Serve its directory with
+// http.FileServer under baseURL to make the URLs reachable.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a store rooted at dir whose URLs start with baseURL
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir returns the directory blobs are written to
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, filepath.Clean(s.dir)+string(filepath.Separator)) {
+		return "", errors.New("invalid key")
+	}
+	return p, nil
+}
+
+// Put writes the blob via a temporary file so readers never see a partial one
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get reads the blob from disk
+func (s *LocalStore) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Delete removes the blob from disk
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL joins the base URL and key
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
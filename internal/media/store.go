@@ -0,0 +1,33 @@
+package media
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by BlobStore.Get for missing keys
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores uploaded files by key
+type BlobStore interface {
+	// Put stores data under key, replacing any existing blob
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns the blob stored under key
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL clients use to fetch the blob
+	URL(key string) string
+}
+
+// DeleteAll deletes every key from store, carrying on past failures, and
+// returns the errors joined
+func DeleteAll(ctx context.Context, store BlobStore, keys []string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareLabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/test-chirps/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Middleware(mux)
+
+	for _, path := range []string{"/api/test-chirps/1", "/api/test-chirps/2", "/nope"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("MADEUP", "/api/test-chirps/3", nil))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`chirpy_http_requests_total{code="418",method="GET",route="/api/test-chirps/"} 2`,
+		`chirpy_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`chirpy_http_requests_total{code="418",method="other",route="/api/test-chirps/"} 1`,
+		`chirpy_http_requests_in_flight 0`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestValueSumsSeries(t *testing.T) {
+	Logins.WithLabelValues("test_a").Add(2)
+	Logins.WithLabelValues("test_b").Add(3)
+
+	got, err := Value("chirpy_logins_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 5 {
+		t.Fatalf("expected 5, got %v", got)
+	}
+}
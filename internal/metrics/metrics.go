@@ -0,0 +1,86 @@
+// Package metrics holds the Prometheus registry for the server: HTTP
+// request metrics, database pool stats, Go runtime metrics and business
+// counters. The /metrics endpoint and the admin page both read from it.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+// Registry is the registry every metric of the server is registered with
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Business counters
+var (
+	FileserverHits = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fileserver_hits_total",
+		Help:      "Requests served by the /app/ fileserver.",
+	})
+	ChirpsCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chirps_created_total",
+		Help:      "Chirps created, by status (published, draft or scheduled).",
+	}, []string{"status"})
+	Logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result.",
+	}, []string{"result"})
+	WebhookEvents = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_total",
+		Help:      "Polka webhook events received, by event type.",
+	}, []string{"event"})
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by a rate limit, by policy.",
+	}, []string{"policy"})
+)
+
+// RegisterDB exports the connection pool stats of db
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Value returns the sum of all series of the named metric family, or 0 if
+// it has no series yet. Only counters and gauges are supported.
+func Value(name string) (float64, error) {
+	families, err := Registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			total += m.GetCounter().GetValue() + m.GetGauge().GetValue()
+		}
+	}
+	return total, nil
+}
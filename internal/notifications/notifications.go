@@ -0,0 +1,180 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+// Notification types
+const (
+	TypeMention  = "mention"
+	TypeReply    = "reply"
+	TypeUpgraded = "upgraded"
+)
+
+// Types lists every notification type a user can toggle
+var Types = []string{TypeMention, TypeReply, TypeUpgraded}
+
+// IsValidType reports whether t is a known notification type
+func IsValidType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is the JSON representation returned to clients and
+// published as a notification.created event
+type Notification struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	ChirpID   string     `json:"chirp_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+// FromRow converts a database row into its JSON representation
+func FromRow(n database.Notification) Notification {
+	out := Notification{
+		ID:        n.ID.String(),
+		CreatedAt: n.CreatedAt,
+		UserID:    n.UserID.String(),
+		Type:      n.Type,
+	}
+	if n.ActorID.Valid {
+		out.ActorID = n.ActorID.UUID.String()
+	}
+	if n.ChirpID.Valid {
+		out.ChirpID = n.ChirpID.UUID.String()
+	}
+	if n.ReadAt.Valid {
+		out.ReadAt = &n.ReadAt.Time
+	}
+	return out
+}
+
+// ForChirp records reply and mention notifications for a new chirp, and the
+// mentioned users who may see it whatever its visibility.
+// parentAuthorID is the author of the chirp being replied to, or uuid.Nil.
+// Use Queries bound to the transaction that created the chirp.
+func ForChirp(ctx context.Context, q *database.Queries, chirp database.Chirp, parentAuthorID uuid.UUID) error {
+	notified := map[uuid.UUID]bool{chirp.UserID: true}
+
+	if parentAuthorID != uuid.Nil && !notified[parentAuthorID] {
+		notified[parentAuthorID] = true
+		if err := create(ctx, q, parentAuthorID, TypeReply, chirp.UserID, chirp.ID); err != nil {
+			return err
+		}
+	}
+
+	var emails, handles []string
+	for _, mention := range ParseMentions(chirp.Body) {
+		if strings.Contains(mention, "@") {
+			emails = append(emails, mention)
+		} else {
+			handles = append(handles, strings.ToLower(mention))
+		}
+	}
+
+	var userIDs []uuid.UUID
+	if len(emails) > 0 {
+		ids, err := q.GetUserIDsByEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, ids...)
+	}
+	if len(handles) > 0 {
+		ids, err := q.GetUserIDsByHandles(ctx, handles)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, ids...)
+	}
+
+	for _, userID := range userIDs {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		// Users who blocked each other can't mention one another
+		blocked, err := q.HasBlockBetweenAny(ctx, database.HasBlockBetweenAnyParams{
+			UserID:   chirp.UserID,
+			OtherIds: []uuid.UUID{userID},
+		})
+		if err != nil {
+			return err
+		}
+		if blocked {
+			continue
+		}
+		err = q.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  userID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := create(ctx, q, userID, TypeMention, chirp.UserID, chirp.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForUpgrade records that the user was upgraded to Chirpy Red
+func ForUpgrade(ctx context.Context, q *database.Queries, userID uuid.UUID) error {
+	return create(ctx, q, userID, TypeUpgraded, uuid.Nil, uuid.Nil)
+}
+
+// create stores a notification unless the recipient disabled its type, and
+// queues a notification.created event for live delivery
+func create(ctx context.Context, q *database.Queries, userID uuid.UUID, typ string, actorID, chirpID uuid.UUID) error {
+	n, err := q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  userID,
+		Type:    typ,
+		ActorID: uuid.NullUUID{UUID: actorID, Valid: actorID != uuid.Nil},
+		ChirpID: uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Disabled by the recipient's preferences
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return outbox.Enqueue(ctx, q, outbox.TopicNotificationCreated, n.ID, FromRow(n))
+}
+
+// ParseMentions returns the distinct handles or email addresses mentioned in
+// body, e.g. "@walt" or "@walt@example.com"
+func ParseMentions(body string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+
+	for _, word := range strings.Fields(body) {
+		if !strings.HasPrefix(word, "@") {
+			continue
+		}
+		mention := strings.TrimRight(strings.TrimPrefix(word, "@"), ".,!?:;)")
+		if mention == "" || seen[mention] {
+			continue
+		}
+		seen[mention] = true
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
@@ -0,0 +1,27 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"hey @walt@example.com!", []string{"walt@example.com"}},
+		{"@a@x.io and @b@y.io, also @a@x.io", []string{"a@x.io", "b@y.io"}},
+		{"email me at jesse@example.com or @jesse.", []string{"jesse"}},
+		{"@walt and @walt@example.com", []string{"walt", "walt@example.com"}},
+		{"a lone @ sign", nil},
+	}
+
+	for _, tt := range tests {
+		got := ParseMentions(tt.body)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMentions(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const natsTimeout = 5 * time.Second
+
+// NATSSink publishes events to a NATS-compatible broker using the plain text
+// protocol. Each event goes to "<prefix>.<topic>" and is followed by a PING so
+// Publish only returns once the broker has processed the message.
+type NATSSink struct {
+	addr   string
+	prefix string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSSink creates a sink for the broker at addr ("host:port" or
+// "nats://host:port"). The connection is opened lazily.
+func NewNATSSink(addr, prefix string) *NATSSink {
+	return &NATSSink{
+		addr:   strings.TrimPrefix(addr, "nats://"),
+		prefix: prefix,
+	}
+}
+
+// Publish sends the event and waits for the broker to acknowledge it
+func (s *NATSSink) Publish(ctx context.Context, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := s.publish(ev.Topic, data); err != nil {
+		// Drop the connection so the next attempt starts clean
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the broker connection, if any
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: natsTimeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO before accepting commands
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		conn.Close()
+		return fmt.Errorf("nats sink: unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	if _, err := conn.Write([]byte("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"chirpy-outbox\"}\r\n")); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	s.reader = reader
+	return nil
+}
+
+func (s *NATSSink) publish(topic string, data []byte) error {
+	s.conn.SetDeadline(time.Now().Add(natsTimeout))
+
+	subject := topic
+	if s.prefix != "" {
+		subject = s.prefix + "." + topic
+	}
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data)
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+
+	// Wait for our PONG, answering server PINGs and surfacing errors
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats sink: " + line)
+		}
+	}
+}
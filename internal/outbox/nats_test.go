@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeBroker accepts one connection, speaks just enough of the NATS protocol
+// and reports every published subject on the returned channel.
+func fakeBroker(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	subjects := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch fields[0] {
+			case "PUB":
+				subjects <- fields[1]
+				r.ReadString('\n') // payload
+			case "PING":
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+
+	return ln.Addr().String(), subjects
+}
+
+func TestNATSSinkPublish(t *testing.T) {
+	addr, subjects := fakeBroker(t)
+
+	sink := NewNATSSink("nats://"+addr, "chirpy")
+	defer sink.Close()
+
+	for _, topic := range []string{TopicChirpCreated, TopicChirpDeleted} {
+		if err := sink.Publish(context.Background(), Event{ID: 1, Topic: topic}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := <-subjects; got != "chirpy."+topic {
+			t.Errorf("expected subject chirpy.%s, got %s", topic, got)
+		}
+	}
+}
+
+func TestNATSSinkUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	sink := NewNATSSink(addr, "chirpy")
+	if err := sink.Publish(context.Background(), Event{ID: 1, Topic: TopicChirpCreated}); err == nil {
+		t.Fatal("expected error publishing to closed broker, got nil")
+	}
+}
@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Topics written to the outbox
+const (
+	TopicChirpCreated  = "chirp.created"
+	TopicChirpDeleted  = "chirp.deleted"
+	TopicChirpRestored = "chirp.restored"
+	TopicUserUpgraded  = "user.upgraded"
+	TopicUserDeleted   = "user.deleted"
+
+	TopicNotificationCreated = "notification.created"
+)
+
+// Event is the envelope delivered to sinks
+type Event struct {
+	ID          int64           `json:"id"`
+	Topic       string          `json:"topic"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+
+	// Traceparent is the W3C trace context of the request that wrote the
+	// event; sinks that support headers pass it on
+	Traceparent string `json:"-"`
+}
+
+// Enqueue stores an event in the outbox. Call it with Queries bound to the
+// same transaction as the write it describes so both commit or neither does.
+// The trace context of ctx is stored with the event so delivery continues
+// the same trace.
+func Enqueue(ctx context.Context, q *database.Queries, topic string, aggregateID uuid.UUID, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	_, err = q.CreateOutboxEvent(ctx, database.CreateOutboxEventParams{
+		Topic:       topic,
+		AggregateID: aggregateID,
+		Payload:     data,
+		Traceparent: carrier.Get("traceparent"),
+	})
+	return err
+}
+
+// eventFromRow converts a stored outbox row into the public envelope
+func eventFromRow(row database.OutboxEvent) Event {
+	return Event{
+		ID:          row.ID,
+		Topic:       row.Topic,
+		AggregateID: row.AggregateID,
+		Payload:     row.Payload,
+		CreatedAt:   row.CreatedAt,
+		Traceparent: row.Traceparent,
+	}
+}
@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	maxRetryBackoff     = 5 * time.Minute
+	// claimLease is how long claimed events are hidden from other relays
+	// while they are being published; it outlasts a full batch of webhook
+	// deliveries hitting their timeout
+	claimLease = 20 * time.Minute
+
+	// Published events are kept for publishedRetention for debugging and
+	// then deleted, sweepBatchSize at a time every sweepInterval
+	publishedRetention = 7 * 24 * time.Hour
+	sweepInterval      = time.Hour
+	sweepBatchSize     = 1000
+)
+
+// Relay publishes pending outbox events to its sinks.
+//
+// Events are claimed with FOR UPDATE SKIP LOCKED and leased, so several
+// server instances can run a relay against the same database. An event is only marked as
+// published once every sink accepted it, so delivery is at-least-once and
+// sinks must tolerate duplicates (use Event.ID to deduplicate).
+type Relay struct {
+	db           *sql.DB
+	sinks        []Sink
+	batchSize    int32
+	pollInterval time.Duration
+}
+
+// NewRelay creates a relay that publishes to the given sinks
+func NewRelay(db *sql.DB, sinks ...Sink) *Relay {
+	return &Relay{
+		db:           db,
+		sinks:        sinks,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled, deleting old published
+// events every sweepInterval
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	var lastSweep time.Time
+	for {
+		if time.Since(lastSweep) >= sweepInterval {
+			lastSweep = time.Now()
+			if n, err := r.SweepPublished(ctx); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("outbox: sweep failed: %v", err)
+				}
+			} else if n > 0 {
+				log.Printf("outbox: deleted %d published events", n)
+			}
+		}
+
+		// Keep draining while batches come back full
+		for {
+			n, err := r.ProcessBatch(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("outbox: relay batch failed: %v", err)
+				}
+				break
+			}
+			if n < int(r.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims one batch of due events, publishes them and records
+// the outcome. It returns the number of events claimed.
+//
+// Claiming pushes the events' available_at back by claimLease and commits,
+// so other relays skip them while they are published outside any
+// transaction. If this relay dies mid-batch the events become due again
+// once the lease runs out.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	rows, err := database.New(r.db).ClaimOutboxEvents(ctx, database.ClaimOutboxEventsParams{
+		LeaseSeconds: claimLease.Seconds(),
+		BatchSize:    r.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+
+	results := make([]error, len(rows))
+	for i, row := range rows {
+		results[i] = r.publish(ctx, eventFromRow(row))
+	}
+
+	err = database.RunInTx(ctx, r.db, func(q *database.Queries) error {
+		for i, row := range rows {
+			if results[i] == nil {
+				if err := q.MarkOutboxEventPublished(ctx, row.ID); err != nil {
+					return err
+				}
+				continue
+			}
+
+			log.Printf("outbox: event %d (%s) attempt %d failed: %v", row.ID, row.Topic, row.Attempts+1, results[i])
+			if err := q.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+				ID:                row.ID,
+				LastError:         sql.NullString{String: results[i].Error(), Valid: true},
+				RetryAfterSeconds: retryBackoff(row.Attempts).Seconds(),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return len(rows), err
+}
+
+// SweepPublished deletes events published more than publishedRetention ago
+// and returns how many were deleted
+func (r *Relay) SweepPublished(ctx context.Context) (int64, error) {
+	q := database.New(r.db)
+	var deleted int64
+	for {
+		n, err := q.DeletePublishedOutboxEvents(ctx, database.DeletePublishedOutboxEventsParams{
+			RetentionSeconds: publishedRetention.Seconds(),
+			BatchSize:        sweepBatchSize,
+		})
+		deleted += n
+		if err != nil || n < sweepBatchSize {
+			return deleted, err
+		}
+	}
+}
+
+var tracer = otel.Tracer("github.com/xaitan80/go-server/internal/outbox")
+
+// publish delivers the event to every sink, stopping at the first failure.
+// Delivery is traced as a child of the request that wrote the event.
+func (r *Relay) publish(ctx context.Context, ev Event) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": ev.Traceparent})
+	ctx, span := tracer.Start(ctx, "outbox publish "+ev.Topic, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	for _, s := range r.sinks {
+		if err := s.Publish(ctx, ev); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// retryBackoff doubles the delay with each attempt, capped at maxRetryBackoff
+func retryBackoff(attempts int32) time.Duration {
+	if attempts > 16 {
+		return maxRetryBackoff
+	}
+	d := time.Second << attempts
+	if d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return d
+}
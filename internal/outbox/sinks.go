@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/tracing"
+)
+
+// Sink receives events from the relay. Publish must return an error unless
+// the event was durably handed off, otherwise it will not be retried.
+type Sink interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// LogSink writes every event to the standard logger
+type LogSink struct{}
+
+// Publish logs the event
+func (LogSink) Publish(ctx context.Context, ev Event) error {
+	log.Printf("outbox: %s #%d aggregate=%s payload=%s", ev.Topic, ev.ID, ev.AggregateID, ev.Payload)
+	return nil
+}
+
+// HTTPSink POSTs every event as JSON to a fixed URL
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPSink creates an HTTP sink with a bounded request timeout whose
+// requests carry the event's traceparent
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{
+		URL: url,
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
+		},
+	}
+}
+
+// Publish sends the event and treats any non-2xx response as a failure
+func (s *HTTPSink) Publish(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Outbox-Event-ID", strconv.FormatInt(ev.ID, 10))
+	req.Header.Set("X-Outbox-Topic", ev.Topic)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http sink: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package ratelimit throttles requests with token buckets keyed by
+// authenticated user or client IP, following per-route policies.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/logging"
+	"github.com/xaitan80/go-server/internal/metrics"
+)
+
+// Scope selects what a policy counts requests against
+type Scope int
+
+const (
+	// PerUser counts against the authenticated user, falling back to the
+	// client IP for anonymous requests
+	PerUser Scope = iota
+	// PerIP counts against the client IP
+	PerIP
+)
+
+// Policy allows Limit requests per Period on average, with bursts of up to
+// Burst requests (Limit when zero). Routes sharing a policy Name share its
+// buckets.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int
+	Scope  Scope
+}
+
+func (p Policy) burst() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return float64(p.Limit)
+}
+
+// rate is the refill rate in tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Store keeps token buckets. Take refills the bucket for key, takes a token
+// if one is available and returns the tokens left.
+type Store interface {
+	Take(ctx context.Context, key string, burst, rate float64) (allowed bool, tokens float64, err error)
+}
+
+// Limiter applies policies to the routes they are registered for
+type Limiter struct {
+	store   Store
+	routes  *http.ServeMux
+	userID  func(*http.Request) string
+	trusted []netip.Prefix
+}
+
+// New creates a limiter. policies maps ServeMux patterns such as
+// "POST /api/login" to the policy limiting them. userID names the
+// authenticated user of a request, or returns "". X-Forwarded-For is only
+// believed when the connection comes from a trusted proxy.
+func New(store Store, policies map[string]Policy, userID func(*http.Request) string, trusted []netip.Prefix) *Limiter {
+	l := &Limiter{
+		store:   store,
+		routes:  http.NewServeMux(),
+		userID:  userID,
+		trusted: trusted,
+	}
+	for pattern, p := range policies {
+		l.routes.Handle(pattern, policyHandler(p))
+	}
+	return l
+}
+
+// policyHandler only carries a policy through the route lookup
+type policyHandler Policy
+
+func (policyHandler) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// Middleware rejects requests over their route's limit with 429 Too Many
+// Requests. Limited routes report their quota in RateLimit-* headers and
+// rejections say when to retry in Retry-After. If the store fails the
+// request is let through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := l.routes.Handler(r)
+		p, ok := h.(policyHandler)
+		if pattern == "" || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		policy := Policy(p)
+
+		key := policy.Name + ":ip:" + ClientIP(r, l.trusted).String()
+		if policy.Scope == PerUser {
+			if uid := l.userID(r); uid != "" {
+				key = policy.Name + ":user:" + uid
+			}
+		}
+
+		allowed, tokens, err := l.store.Take(r.Context(), key, policy.burst(), policy.rate())
+		if err != nil {
+			logging.FromContext(r.Context()).Error("rate limit check failed", "policy", policy.Name, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, int(math.Ceil(policy.Period.Seconds())), int(policy.burst())))
+		header.Set("RateLimit-Limit", strconv.Itoa(int(policy.burst())))
+		header.Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(tokens))))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(policy.burst()-tokens, policy.rate())))
+
+		if !allowed {
+			metrics.RateLimited.WithLabelValues(policy.Name).Inc()
+			header.Set("Retry-After", strconv.Itoa(seconds(1-tokens, policy.rate())))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(errorResponse{Error: "Too many requests"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// errorResponse matches the API's JSON error format
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// seconds is how long, rounded up, it takes to refill tokens at rate
+func seconds(tokens, rate float64) int {
+	if tokens <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / rate))
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareLimitsPerClient(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	limiter := New(store, map[string]Policy{
+		"POST /api/login": {Name: "login", Limit: 2, Period: time.Minute, Scope: PerIP},
+	}, func(*http.Request) string { return "" }, nil)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/login", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := do(http.MethodPost, "192.0.2.1:1234")
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: status %d, remaining %q", i, rec.Code, rec.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	rec := do(http.MethodPost, "192.0.2.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if got := rec.Header().Get("RateLimit-Policy"); got != "2;w=60;burst=2" {
+		t.Errorf("RateLimit-Policy = %q", got)
+	}
+
+	// Other clients and unlimited routes are unaffected
+	if rec := do(http.MethodPost, "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other client got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "192.0.2.1:1234"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited route got %d with headers %v", rec.Code, rec.Header())
+	}
+
+	// Tokens refill over time
+	now = now.Add(30 * time.Second)
+	if rec := do(http.MethodPost, "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected a refilled token, got %d", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.10/32")}
+
+	for _, tc := range []struct {
+		name, remoteAddr, forwarded, want string
+	}{
+		{"direct client ignores header", "203.0.113.5:1000", "198.51.100.1", "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:1000", "198.51.100.1", "198.51.100.1"},
+		{"chain of proxies", "10.1.2.3:1000", "198.51.100.1, 192.0.2.10", "198.51.100.1"},
+		{"spoofed entries left of the client", "10.1.2.3:1000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"proxy without header", "192.0.2.10:1000", "", "192.0.2.10"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if got := ClientIP(req, trusted).String(); got != tc.want {
+				t.Errorf("ClientIP = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
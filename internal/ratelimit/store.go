@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+// Buckets untouched for longer than idleTTL are dropped. A bucket that has
+// refilled completely is the same as no bucket, so this only forgets state
+// for policies that take longer than idleTTL to refill.
+const (
+	idleTTL       = time.Hour
+	sweepInterval = 10 * time.Minute
+)
+
+// MemoryStore keeps buckets in process memory. Each server instance
+// limits on its own, so use PostgresStore when running several.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, burst, rate float64) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst}
+		s.buckets[key] = b
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+// Run drops idle buckets until ctx is cancelled
+func (s *MemoryStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		cutoff := s.now().Add(-idleTTL)
+		for key, b := range s.buckets {
+			if b.updated.Before(cutoff) {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// server instance shares them. Refills use the database clock.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implements Store. The bucket row is created if needed and locked
+// before the refill is computed, so concurrent requests for the same key
+// take tokens one after another.
+func (s *PostgresStore) Take(ctx context.Context, key string, burst, rate float64) (bool, float64, error) {
+	var row database.TakeRateLimitTokenRow
+
+	err := database.RunInTx(ctx, s.db, func(q *database.Queries) error {
+		if err := q.CreateRateLimitBucket(ctx, database.CreateRateLimitBucketParams{
+			Key:   key,
+			Burst: burst,
+		}); err != nil {
+			return err
+		}
+		if err := q.LockRateLimitBucket(ctx, key); err != nil {
+			return err
+		}
+
+		var err error
+		row, err = q.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+			Key:   key,
+			Burst: burst,
+			Rate:  rate,
+		})
+		return err
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return row.Allowed, row.Tokens, nil
+}
+
+// Run deletes idle buckets until ctx is cancelled
+func (s *PostgresStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := database.New(s.db).DeleteIdleRateLimitBuckets(ctx, idleTTL.Seconds()); err != nil && ctx.Err() == nil {
+			log.Printf("ratelimit: failed to delete idle buckets: %v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// ChirpPurger permanently removes chirps that were soft-deleted more than
+// the retention period ago, along with their media
+type ChirpPurger struct {
+	queries   *database.Queries
+	blobs     media.BlobStore
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewChirpPurger creates a purger with the given retention period that
+// deletes attachments from blobs
+func NewChirpPurger(queries *database.Queries, blobs media.BlobStore, retention time.Duration) *ChirpPurger {
+	return &ChirpPurger{
+		queries:   queries,
+		blobs:     blobs,
+		retention: retention,
+		interval:  defaultPurgeInterval,
+	}
+}
+
+// Run purges expired chirps until ctx is cancelled
+func (p *ChirpPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("retention: chirp purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("retention: purged %d deleted chirps", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every expired chirp and returns how many were removed.
+// Blobs are deleted once their rows are gone; one that fails to delete is
+// logged and left behind.
+func (p *ChirpPurger) PurgeOnce(ctx context.Context) (int, error) {
+	purged, err := p.queries.PurgeDeletedChirps(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range purged {
+		if err := media.DeleteAll(ctx, p.blobs, c.BlobKeys); err != nil {
+			log.Printf("retention: failed to delete media of chirp %s: %v", c.ID, err)
+		}
+	}
+	return len(purged), nil
+}
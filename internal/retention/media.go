@@ -0,0 +1,80 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/media"
+)
+
+// DefaultUnattachedMediaTTL is how long an upload may wait to be attached
+// to a chirp before it is removed
+const DefaultUnattachedMediaTTL = 24 * time.Hour
+
+const sweepBatchSize = 100
+
+// MediaSweeper removes uploads that were never attached to a chirp
+type MediaSweeper struct {
+	queries  *database.Queries
+	blobs    media.BlobStore
+	ttl      time.Duration
+	interval time.Duration
+}
+
+// NewMediaSweeper creates a sweeper for uploads left unattached for longer
+// than ttl
+func NewMediaSweeper(queries *database.Queries, blobs media.BlobStore, ttl time.Duration) *MediaSweeper {
+	return &MediaSweeper{
+		queries:  queries,
+		blobs:    blobs,
+		ttl:      ttl,
+		interval: defaultPurgeInterval,
+	}
+}
+
+// Run sweeps unattached media until ctx is cancelled
+func (s *MediaSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.SweepOnce(ctx); err != nil {
+			log.Printf("retention: media sweep failed: %v", err)
+		} else if n > 0 {
+			log.Printf("retention: removed %d unattached uploads", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce removes every expired unattached upload, a batch at a time, and
+// returns how many were removed
+func (s *MediaSweeper) SweepOnce(ctx context.Context) (int, error) {
+	var swept int
+	for {
+		rows, err := s.queries.DeleteUnattachedMedia(ctx, database.DeleteUnattachedMediaParams{
+			TtlSeconds: s.ttl.Seconds(),
+			BatchSize:  sweepBatchSize,
+		})
+		if err != nil {
+			return swept, err
+		}
+		swept += len(rows)
+
+		for _, m := range rows {
+			if err := media.DeleteAll(ctx, s.blobs, []string{m.StorageKey, m.ThumbnailKey}); err != nil {
+				log.Printf("retention: failed to delete upload %s: %v", m.StorageKey, err)
+			}
+		}
+		if len(rows) < sweepBatchSize {
+			return swept, nil
+		}
+	}
+}
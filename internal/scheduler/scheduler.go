@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xaitan80/go-server/internal/database"
+)
+
+const (
+	defaultBatchSize    = 50
+	defaultPollInterval = 5 * time.Second
+
+	// A chirp that fails to publish is retried after retryDelay, and goes
+	// back to being a draft after maxAttempts failures
+	retryDelay  = time.Minute
+	maxAttempts = 5
+)
+
+// PublishFunc runs the side effects of publishing a chirp (notifications,
+// outbox events) with Queries bound to the publishing transaction
+type PublishFunc func(ctx context.Context, q *database.Queries, chirp database.Chirp) error
+
+// Scheduler publishes scheduled chirps once they are due.
+//
+// Each due chirp is claimed with FOR UPDATE SKIP LOCKED and published in its
+// own transaction, so every server instance can run a scheduler, each chirp
+// is still published once, and a chirp that fails doesn't hold up the rest.
+type Scheduler struct {
+	db           *sql.DB
+	publish      PublishFunc
+	batchSize    int32
+	pollInterval time.Duration
+}
+
+// New creates a scheduler that calls publish for every chirp it publishes
+func New(db *sql.DB, publish PublishFunc) *Scheduler {
+	return &Scheduler{
+		db:           db,
+		publish:      publish,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// Run publishes due chirps until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.ProcessBatch(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("scheduler: batch failed: %v", err)
+				}
+				break
+			}
+			if n < int(s.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch publishes one batch of due chirps and returns the number
+// published. Chirps that fail to publish have the error recorded on their
+// row and are retried later; only failing to list the batch is an error.
+func (s *Scheduler) ProcessBatch(ctx context.Context) (int, error) {
+	ids, err := database.New(s.db).ListDueChirpIDs(ctx, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return publishEach(ctx, ids, s.publishOne, s.recordFailure)
+}
+
+// publishEach calls publishOne for every ID, passing failures to fail
+// instead of stopping. It returns the number of chirps published.
+func publishEach(
+	ctx context.Context,
+	ids []uuid.UUID,
+	publishOne func(context.Context, uuid.UUID) (bool, error),
+	fail func(context.Context, uuid.UUID, error),
+) (int, error) {
+	var published int
+	for _, id := range ids {
+		ok, err := publishOne(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return published, ctx.Err()
+			}
+			fail(ctx, id, err)
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// publishOne publishes a due chirp in its own transaction. It returns false
+// if the chirp is no longer due or another instance is publishing it.
+func (s *Scheduler) publishOne(ctx context.Context, id uuid.UUID) (bool, error) {
+	var published bool
+
+	err := database.RunInTx(ctx, s.db, func(q *database.Queries) error {
+		if _, err := q.ClaimDueChirp(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		chirp, err := q.PublishChirp(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.publish(ctx, q, chirp); err != nil {
+			return err
+		}
+		published = true
+		return nil
+	})
+
+	return published, err
+}
+
+// recordFailure stores a publish error on the chirp so it is retried later,
+// or returned to its author as a draft once it has failed too often
+func (s *Scheduler) recordFailure(ctx context.Context, id uuid.UUID, publishErr error) {
+	log.Printf("scheduler: failed to publish chirp %s: %v", id, publishErr)
+
+	status, err := database.New(s.db).FailScheduledChirp(ctx, database.FailScheduledChirpParams{
+		ID:                id,
+		PublishError:      sql.NullString{String: publishErr.Error(), Valid: true},
+		RetryAfterSeconds: retryDelay.Seconds(),
+		MaxAttempts:       maxAttempts,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("scheduler: failed to record failure for chirp %s: %v", id, err)
+		}
+		return
+	}
+	if status != "scheduled" {
+		log.Printf("scheduler: gave up on chirp %s after %d attempts", id, maxAttempts)
+	}
+}
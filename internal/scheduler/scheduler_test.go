@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishEachContinuesPastFailures(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	broken := ids[1]
+	taken := ids[2]
+
+	var attempted []uuid.UUID
+	publishOne := func(ctx context.Context, id uuid.UUID) (bool, error) {
+		attempted = append(attempted, id)
+		switch id {
+		case broken:
+			return false, errors.New("notification failed")
+		case taken:
+			return false, nil
+		}
+		return true, nil
+	}
+	var failed []uuid.UUID
+	fail := func(ctx context.Context, id uuid.UUID, err error) {
+		failed = append(failed, id)
+	}
+
+	n, err := publishEach(context.Background(), ids, publishOne, fail)
+	if err != nil {
+		t.Fatalf("publishEach: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("published %d chirps, want 2", n)
+	}
+	if len(attempted) != len(ids) {
+		t.Errorf("attempted %d chirps, want all %d", len(attempted), len(ids))
+	}
+	if len(failed) != 1 || failed[0] != broken {
+		t.Errorf("failures recorded for %v, want only %s", failed, broken)
+	}
+}
+
+func TestPublishEachStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	calls := 0
+	publishOne := func(ctx context.Context, id uuid.UUID) (bool, error) {
+		calls++
+		cancel()
+		return false, ctx.Err()
+	}
+	fail := func(ctx context.Context, id uuid.UUID, err error) {
+		t.Errorf("failure recorded for %s after shutdown", id)
+	}
+
+	if _, err := publishEach(ctx, ids, publishOne, fail); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("publishOne called %d times after cancellation, want 1", calls)
+	}
+}
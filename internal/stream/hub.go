@@ -0,0 +1,192 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many events may queue for a subscriber before it is
+// considered too slow and dropped
+const subscriberBuffer = 64
+
+// Event is an event delivered to streaming clients. Chirp events carry the
+// chirp author; user-scoped events carry the user they are addressed to.
+type Event struct {
+	ID          int64
+	Type        string
+	AuthorID    uuid.UUID
+	RecipientID uuid.UUID
+	Data        json.RawMessage
+}
+
+// Filter limits a subscription. Zero-valued fields match every event.
+type Filter struct {
+	TypePrefix  string
+	AuthorID    uuid.UUID
+	RecipientID uuid.UUID
+}
+
+func (f Filter) matches(ev Event) bool {
+	if !strings.HasPrefix(ev.Type, f.TypePrefix) {
+		return false
+	}
+	if f.AuthorID != uuid.Nil && f.AuthorID != ev.AuthorID {
+		return false
+	}
+	return f.RecipientID == uuid.Nil || f.RecipientID == ev.RecipientID
+}
+
+// Subscription receives events on C until it is closed. C is closed when the
+// subscriber falls too far behind, Unsubscribe is called or the hub closes.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+}
+
+// Hub fans events out to local subscribers and keeps a bounded history so
+// reconnecting clients can resume from their last seen event ID.
+type Hub struct {
+	mu   sync.Mutex
+	ring []Event
+	next int
+	full bool
+	seen map[int64]struct{}
+	subs map[*Subscription]struct{}
+
+	closed bool
+}
+
+// NewHub creates a hub that remembers the last size events
+func NewHub(size int) *Hub {
+	return &Hub{
+		ring: make([]Event, size),
+		seen: make(map[int64]struct{}, size),
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish records the event and delivers it to matching subscribers.
+// Events already in the history are ignored, so redelivery is harmless.
+func (h *Hub) Publish(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.seen[ev.ID]; ok {
+		return
+	}
+
+	if h.full {
+		delete(h.seen, h.ring[h.next].ID)
+	}
+	h.ring[h.next] = ev
+	h.seen[ev.ID] = struct{}{}
+	h.next = (h.next + 1) % len(h.ring)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.matches(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// Slow consumer: drop it, the client resumes with Last-Event-ID
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. If lastEventID is non-zero, the matching
+// events published after it that are still in the history are returned as
+// backlog; the subscription only receives events published afterwards.
+func (h *Hub) Subscribe(filter Filter, lastEventID int64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+	if h.closed {
+		close(ch)
+		return sub, nil
+	}
+	h.subs[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil
+	}
+
+	history := h.history()
+
+	// Resume right after the last seen event when we still have it,
+	// otherwise fall back to everything newer than it
+	start := -1
+	for i, ev := range history {
+		if ev.ID == lastEventID {
+			start = i + 1
+			break
+		}
+	}
+
+	var backlog []Event
+	for i, ev := range history {
+		if start >= 0 && i < start {
+			continue
+		}
+		if start < 0 && ev.ID <= lastEventID {
+			continue
+		}
+		if filter.matches(ev) {
+			backlog = append(backlog, ev)
+		}
+	}
+	return sub, backlog
+}
+
+// Unsubscribe removes the subscriber and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Close ends every subscription and turns new ones away, so streaming
+// clients disconnect when the server shuts down
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+// Closed reports whether Close has been called
+func (h *Hub) Closed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.closed
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// history returns the buffered events, oldest first
+func (h *Hub) history() []Event {
+	if !h.full {
+		return append([]Event(nil), h.ring[:h.next]...)
+	}
+	out := make([]Event, 0, len(h.ring))
+	out = append(out, h.ring[h.next:]...)
+	return append(out, h.ring[:h.next]...)
+}
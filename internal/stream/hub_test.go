@@ -0,0 +1,129 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubDeliversMatchingEvents(t *testing.T) {
+	hub := NewHub(10)
+	author := uuid.New()
+
+	sub, backlog := hub.Subscribe(Filter{AuthorID: author}, 0)
+	if len(backlog) != 0 {
+		t.Fatalf("expected empty backlog, got %d events", len(backlog))
+	}
+
+	hub.Publish(Event{ID: 1, Type: "chirp.created", AuthorID: uuid.New()})
+	hub.Publish(Event{ID: 2, Type: "chirp.created", AuthorID: author})
+
+	ev := <-sub.C
+	if ev.ID != 2 {
+		t.Errorf("expected event 2, got %d", ev.ID)
+	}
+
+	hub.Unsubscribe(sub)
+	if _, ok := <-sub.C; ok {
+		t.Error("expected channel to be closed after unsubscribe")
+	}
+}
+
+func TestHubCloseEndsSubscriptions(t *testing.T) {
+	hub := NewHub(10)
+	sub, _ := hub.Subscribe(Filter{}, 0)
+
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("expected channel to be closed when the hub closes")
+	}
+
+	late, _ := hub.Subscribe(Filter{}, 0)
+	if _, ok := <-late.C; ok {
+		t.Error("expected subscriptions after Close to be closed")
+	}
+	hub.Unsubscribe(late)
+}
+
+func TestHubResumeFromLastEventID(t *testing.T) {
+	hub := NewHub(3)
+	for id := int64(1); id <= 5; id++ {
+		hub.Publish(Event{ID: id})
+	}
+	// Redelivered events are ignored
+	hub.Publish(Event{ID: 4})
+
+	_, backlog := hub.Subscribe(Filter{}, 3)
+	if len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+		t.Fatalf("expected events 4 and 5, got %+v", backlog)
+	}
+
+	// Event 1 was evicted, so everything newer is replayed
+	_, backlog = hub.Subscribe(Filter{}, 1)
+	if len(backlog) != 3 || backlog[0].ID != 3 {
+		t.Fatalf("expected events 3 to 5, got %+v", backlog)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(subscriberBuffer * 2)
+	sub, _ := hub.Subscribe(Filter{}, 0)
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		hub.Publish(Event{ID: id})
+	}
+
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("expected %d buffered events before drop, got %d", subscriberBuffer, count)
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	author := uuid.New()
+	recipient := uuid.New()
+	chirp := Event{Type: "chirp.created", AuthorID: author}
+	upgrade := Event{Type: "user.upgraded", RecipientID: recipient}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		ev     Event
+		want   bool
+	}{
+		{"all chirps", Filter{TypePrefix: "chirp."}, chirp, true},
+		{"chirps excludes user events", Filter{TypePrefix: "chirp."}, upgrade, false},
+		{"author feed", Filter{TypePrefix: "chirp.", AuthorID: author}, chirp, true},
+		{"other author", Filter{TypePrefix: "chirp.", AuthorID: recipient}, chirp, false},
+		{"own notifications", Filter{RecipientID: recipient}, upgrade, true},
+		{"notifications exclude chirps", Filter{RecipientID: recipient}, chirp, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.matches(tt.ev); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDecodeNotificationSkipsNonPublicChirps(t *testing.T) {
+	author := uuid.New()
+	public := `{"id":1,"topic":"chirp.created","payload":{"user_id":"` + author.String() + `","visibility":"public"}}`
+	followers := `{"id":2,"topic":"chirp.created","payload":{"user_id":"` + author.String() + `","visibility":"followers"}}`
+
+	ev, err := decodeNotification(public)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev.AuthorID != author {
+		t.Errorf("expected author %s, got %s", author, ev.AuthorID)
+	}
+
+	if _, err := decodeNotification(followers); !errors.Is(err, errNotPublic) {
+		t.Errorf("expected errNotPublic, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/xaitan80/go-server/internal/outbox"
+)
+
+// NotifyChannel is the Postgres channel used to fan events out to every
+// server instance
+const NotifyChannel = "chirp_events"
+
+// errNotPublic marks chirp events that must not be broadcast because the
+// chirp is only visible to some users
+var errNotPublic = errors.New("chirp is not public")
+
+// NotifySink is an outbox sink that broadcasts events with pg_notify
+type NotifySink struct {
+	db *sql.DB
+}
+
+// NewNotifySink creates a sink that notifies through db
+func NewNotifySink(db *sql.DB) *NotifySink {
+	return &NotifySink{db: db}
+}
+
+// Publish sends the event to NotifyChannel
+func (s *NotifySink) Publish(ctx context.Context, ev outbox.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", NotifyChannel, string(data))
+	return err
+}
+
+// Listen subscribes to NotifyChannel and publishes every notification into
+// hub until ctx is cancelled.
+func Listen(ctx context.Context, dbURL string, hub *Hub) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("stream: listener event %d: %v", ev, err)
+		}
+	})
+	defer listener.Close()
+	// Listen blocks until the first connection succeeds; closing the
+	// listener on cancel unblocks it when the database is unreachable
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	if err := listener.Listen(NotifyChannel); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ping.C:
+			go listener.Ping()
+		case n := <-listener.Notify:
+			// nil means the connection was re-established
+			if n == nil {
+				continue
+			}
+			ev, err := decodeNotification(n.Extra)
+			if errors.Is(err, errNotPublic) {
+				continue
+			}
+			if err != nil {
+				log.Printf("stream: dropping malformed notification: %v", err)
+				continue
+			}
+			hub.Publish(ev)
+		}
+	}
+}
+
+// decodeNotification turns an outbox envelope into a stream event
+func decodeNotification(payload string) (Event, error) {
+	var env outbox.Event
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		return Event{}, err
+	}
+
+	// Chirp payloads name their author in user_id, every other payload
+	// names the user the event is about
+	var subject struct {
+		UserID     uuid.UUID `json:"user_id"`
+		Visibility string    `json:"visibility"`
+	}
+	if err := json.Unmarshal(env.Payload, &subject); err != nil {
+		return Event{}, err
+	}
+
+	ev := Event{
+		ID:   env.ID,
+		Type: env.Topic,
+		Data: env.Payload,
+	}
+	if strings.HasPrefix(env.Topic, "chirp.") {
+		if subject.Visibility != "" && subject.Visibility != "public" {
+			return Event{}, errNotPublic
+		}
+		ev.AuthorID = subject.UserID
+	} else {
+		ev.RecipientID = subject.UserID
+	}
+	return ev, nil
+}
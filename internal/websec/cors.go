@@ -0,0 +1,92 @@
+// Package websec protects the API for browser clients: CORS, default
+// security headers and cookie sessions guarded by double-submit CSRF tokens.
+package websec
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS describes which cross-origin browser clients may call the API
+type CORS struct {
+	// AllowedOrigins lists origins like https://app.example; "*" allows any
+	// origin, but never with AllowCredentials
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowCredentials lets browsers send cookies with cross-origin requests
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// Request headers cross-origin clients may send, and response headers they
+// may read
+var (
+	corsAllowedHeaders = []string{"Authorization", "Content-Type", CSRFHeader, "Last-Event-ID", "X-Request-ID"}
+	corsExposedHeaders = []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"}
+)
+
+// Middleware adds CORS headers for allowed origins and answers preflight
+// requests itself. Requests from other origins are served without CORS
+// headers, so browsers won't expose the response.
+func (c CORS) Middleware(next http.Handler) http.Handler {
+	methods := strings.Join(c.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(corsAllowedHeaders, ", ")
+	exposedHeaders := strings.Join(corsExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(c.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" || !c.allows(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if slices.Contains(c.AllowedOrigins, "*") && !c.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", methods)
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			if c.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", exposedHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c CORS) allows(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
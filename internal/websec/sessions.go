@@ -0,0 +1,123 @@
+package websec
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Cookie and header names for cookie sessions
+const (
+	SessionCookie = "chirpy_session"
+	CSRFCookie    = "chirpy_csrf"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// Sessions lets browsers authenticate with an HttpOnly cookie holding the
+// access token instead of an Authorization header.
+//
+// Because browsers attach cookies to cross-site requests too, state-changing
+// requests must also echo the CSRF cookie in the X-CSRF-Token header (the
+// double-submit pattern): other sites can make the browser send the cookie
+// but can't read it. A web client on another origin can't read the cookie
+// either, so Start also returns the token for the login response.
+type Sessions struct {
+	// CrossSite marks the cookies SameSite=None so a web client on another
+	// site can use them; otherwise they are SameSite=Lax
+	CrossSite bool
+	// Insecure leaves the Secure attribute off so the cookies work over
+	// plain HTTP in local development. Browsers drop SameSite=None cookies
+	// that aren't Secure, so it has no effect with CrossSite.
+	Insecure bool
+}
+
+// Start sets the session and CSRF cookies for an access token valid for ttl
+// and returns the CSRF token. Sessions aren't refreshed, so they end when
+// the access token expires.
+func (s *Sessions) Start(w http.ResponseWriter, token string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	csrf := hex.EncodeToString(b)
+	http.SetCookie(w, s.cookie(SessionCookie, token, int(ttl.Seconds()), true))
+	http.SetCookie(w, s.cookie(CSRFCookie, csrf, int(ttl.Seconds()), false))
+	return csrf, nil
+}
+
+// End clears both cookies
+func (s *Sessions) End(w http.ResponseWriter) {
+	http.SetCookie(w, s.cookie(SessionCookie, "", -1, true))
+	http.SetCookie(w, s.cookie(CSRFCookie, "", -1, false))
+}
+
+func (s *Sessions) cookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	if s.CrossSite {
+		sameSite = http.SameSiteNoneMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   !s.Insecure || s.CrossSite,
+		SameSite: sameSite,
+	}
+}
+
+// Token returns the access token from the session cookie, or ""
+func (s *Sessions) Token(r *http.Request) string {
+	c, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// Middleware passes the session cookie on to handlers as a bearer token.
+// Requests that change state must carry a matching X-CSRF-Token header or
+// are rejected with 403. Requests with their own Authorization header are
+// left alone, as browsers never add that header by themselves.
+func (s *Sessions) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.Token(r)
+		if token == "" || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !validCSRF(r) {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(errorResponse{Error: "Invalid CSRF token"})
+				return
+			}
+		}
+
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+token)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validCSRF reports whether the CSRF header matches the CSRF cookie
+func validCSRF(r *http.Request) bool {
+	c, err := r.Cookie(CSRFCookie)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(c.Value)) == 1
+}
+
+// errorResponse matches the API's JSON error format
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,170 @@
+package websec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	called := false
+	handler := CORS{
+		AllowedOrigins:   []string{"https://spa.example"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/chirps", nil)
+	req.Header.Set("Origin", "https://spa.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if called || rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight should be answered by the middleware, got %d (handler called: %v)", rec.Code, called)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://spa.example",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/chirps", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !called || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("other origins should be served without CORS headers, got %v", rec.Header())
+	}
+}
+
+func TestSessionsRequireCSRFToken(t *testing.T) {
+	sessions := &Sessions{}
+	login := httptest.NewRecorder()
+	csrf, err := sessions.Start(login, "access-token", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := login.Result().Cookies()
+	for _, c := range cookies {
+		if c.Name == CSRFCookie && c.Value != csrf {
+			t.Errorf("CSRF cookie %q doesn't match the returned token %q", c.Value, csrf)
+		}
+		if c.Name == SessionCookie && (!c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode) {
+			t.Errorf("session cookie should be HttpOnly, Secure and SameSite=Lax: %+v", c)
+		}
+	}
+
+	var gotAuth string
+	handler := sessions.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	do := func(method, csrfHeader string) int {
+		gotAuth = ""
+		req := httptest.NewRequest(method, "/api/chirps", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		if csrfHeader != "" {
+			req.Header.Set(CSRFHeader, csrfHeader)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(http.MethodGet, ""); code != http.StatusOK || gotAuth != "Bearer access-token" {
+		t.Errorf("GET: status %d, Authorization %q", code, gotAuth)
+	}
+	if code := do(http.MethodPost, ""); code != http.StatusForbidden || gotAuth != "" {
+		t.Errorf("POST without CSRF token: status %d, Authorization %q", code, gotAuth)
+	}
+	if code := do(http.MethodPost, "forged"); code != http.StatusForbidden {
+		t.Errorf("POST with wrong CSRF token: status %d", code)
+	}
+	if code := do(http.MethodPost, csrf); code != http.StatusOK || gotAuth != "Bearer access-token" {
+		t.Errorf("POST with CSRF token: status %d, Authorization %q", code, gotAuth)
+	}
+}
+
+// A web client on another origin can't read the API's cookies, so it must
+// get by with the login response body
+func TestSessionsCrossOrigin(t *testing.T) {
+	sessions := &Sessions{CrossSite: true, Insecure: true}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
+		csrf, err := sessions.Start(w, "access-token", time.Hour)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"csrf_token": csrf})
+	})
+	var gotAuth string
+	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	})
+	srv := httptest.NewTLSServer(CORS{
+		AllowedOrigins:   []string{"https://spa.example"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowCredentials: true,
+	}.Middleware(sessions.Middleware(mux)))
+	defer srv.Close()
+
+	// The jar stands in for the browser, which keeps the cookies for the
+	// API host out of the client's reach
+	client := srv.Client()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Jar = jar
+
+	post := func(path, csrf string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", "https://spa.example")
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post("/api/login", "")
+	var login struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&login)
+	resp.Body.Close()
+	if err != nil || login.CSRFToken == "" {
+		t.Fatalf("login response has no CSRF token (err %v)", err)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("login response not readable cross-origin, Access-Control-Allow-Credentials = %q", got)
+	}
+	for _, c := range resp.Cookies() {
+		if !c.Secure || c.SameSite != http.SameSiteNoneMode {
+			t.Errorf("cross-site cookie should be Secure and SameSite=None: %+v", c)
+		}
+	}
+
+	resp = post("/api/chirps", login.CSRFToken)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || gotAuth != "Bearer access-token" {
+		t.Errorf("POST with the returned CSRF token: status %d, Authorization %q", resp.StatusCode, gotAuth)
+	}
+}
@@ -0,0 +1,278 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/stream"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingInterval   = 30 * time.Second
+	maxMessageSize = 4096
+	sendBuffer     = 64
+)
+
+// Custom close code sent when the access token used to connect expires
+const CloseTokenExpired = 4001
+
+// Channels a client can subscribe to
+const (
+	ChannelChirps        = "chirps"
+	ChannelAuthorPrefix  = "chirps:"
+	ChannelNotifications = "notifications"
+)
+
+// clientMessage is a command sent by the client
+type clientMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+// serverMessage is sent to the client
+type serverMessage struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	ID      int64           `json:"id,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Session is one authenticated WebSocket connection
+type Session struct {
+	conn      *websocket.Conn
+	hub       *stream.Hub
+	userID    uuid.UUID
+	jwtSecret string
+
+	send      chan serverMessage
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	subs   map[string]*stream.Subscription
+	expiry *time.Timer
+}
+
+// NewSession wraps an upgraded connection for userID whose token expires at
+// expiresAt (zero for never)
+func NewSession(conn *websocket.Conn, hub *stream.Hub, userID uuid.UUID, expiresAt time.Time, jwtSecret string) *Session {
+	s := &Session{
+		conn:      conn,
+		hub:       hub,
+		userID:    userID,
+		jwtSecret: jwtSecret,
+		send:      make(chan serverMessage, sendBuffer),
+		done:      make(chan struct{}),
+		subs:      make(map[string]*stream.Subscription),
+	}
+	s.scheduleExpiry(expiresAt)
+	return s
+}
+
+// Run serves the session until the connection closes
+func (s *Session) Run() {
+	go s.writeLoop()
+	s.readLoop()
+
+	s.close(websocket.CloseNormalClosure, "")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for channel, sub := range s.subs {
+		delete(s.subs, channel)
+		s.hub.Unsubscribe(sub)
+	}
+	if s.expiry != nil {
+		s.expiry.Stop()
+	}
+}
+
+func (s *Session) readLoop() {
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg clientMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "subscribe":
+			if err := s.subscribe(msg.Channel); err != nil {
+				s.enqueue(serverMessage{Type: "error", Channel: msg.Channel, Error: err.Error()})
+				continue
+			}
+			s.enqueue(serverMessage{Type: "subscribed", Channel: msg.Channel})
+		case "unsubscribe":
+			s.unsubscribe(msg.Channel)
+			s.enqueue(serverMessage{Type: "unsubscribed", Channel: msg.Channel})
+		case "auth":
+			// Swap in a fresh access token to keep the connection alive
+			userID, expiresAt, err := auth.ValidateJWTWithExpiry(msg.Token, s.jwtSecret)
+			if err != nil || userID != s.userID {
+				s.enqueue(serverMessage{Type: "error", Error: "Invalid token"})
+				continue
+			}
+			s.scheduleExpiry(expiresAt)
+			s.enqueue(serverMessage{Type: "authenticated"})
+		default:
+			s.enqueue(serverMessage{Type: "error", Error: "Unknown message type"})
+		}
+	}
+}
+
+func (s *Session) writeLoop() {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				s.close(websocket.CloseInternalServerErr, "write failed")
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				s.close(websocket.CloseGoingAway, "ping failed")
+				return
+			}
+		}
+	}
+}
+
+// filterFor maps a channel name to a hub filter
+func (s *Session) filterFor(channel string) (stream.Filter, error) {
+	switch {
+	case channel == ChannelChirps:
+		return stream.Filter{TypePrefix: "chirp."}, nil
+	case channel == ChannelNotifications:
+		return stream.Filter{RecipientID: s.userID}, nil
+	case strings.HasPrefix(channel, ChannelAuthorPrefix):
+		authorID, err := uuid.Parse(strings.TrimPrefix(channel, ChannelAuthorPrefix))
+		if err != nil {
+			return stream.Filter{}, errors.New("Invalid author ID")
+		}
+		return stream.Filter{TypePrefix: "chirp.", AuthorID: authorID}, nil
+	}
+	return stream.Filter{}, errors.New("Unknown channel")
+}
+
+func (s *Session) subscribe(channel string) error {
+	filter, err := s.filterFor(channel)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[channel]; ok {
+		return nil
+	}
+	sub, _ := s.hub.Subscribe(filter, 0)
+	s.subs[channel] = sub
+	go s.forward(channel, sub)
+	return nil
+}
+
+func (s *Session) unsubscribe(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub, ok := s.subs[channel]; ok {
+		delete(s.subs, channel)
+		s.hub.Unsubscribe(sub)
+	}
+}
+
+// forward copies hub events for one channel into the send queue
+func (s *Session) forward(channel string, sub *stream.Subscription) {
+	for ev := range sub.C {
+		if !s.enqueue(serverMessage{
+			Type:    "event",
+			Channel: channel,
+			ID:      ev.ID,
+			Event:   ev.Type,
+			Data:    ev.Data,
+		}) {
+			return
+		}
+	}
+
+	// The hub closes the channel on unsubscribe, when we fell behind or when
+	// the server shuts down
+	s.mu.Lock()
+	dropped := s.subs[channel] == sub
+	s.mu.Unlock()
+	switch {
+	case dropped && s.hub.Closed():
+		s.close(websocket.CloseGoingAway, "server shutting down")
+	case dropped:
+		s.close(websocket.CloseTryAgainLater, "subscriber too slow")
+	}
+}
+
+// enqueue queues a message without blocking. A full queue means the client
+// is not keeping up, so the connection is closed rather than buffering more.
+func (s *Session) enqueue(msg serverMessage) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	select {
+	case s.send <- msg:
+		return true
+	default:
+		s.close(websocket.CloseTryAgainLater, "send buffer full")
+		return false
+	}
+}
+
+// scheduleExpiry disconnects the client when its token expires
+func (s *Session) scheduleExpiry(expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expiry != nil {
+		s.expiry.Stop()
+		s.expiry = nil
+	}
+	if expiresAt.IsZero() {
+		return
+	}
+	s.expiry = time.AfterFunc(time.Until(expiresAt), func() {
+		s.close(CloseTokenExpired, "token expired")
+	})
+}
+
+// close sends a close frame and tears down the connection once
+func (s *Session) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(code, reason),
+			time.Now().Add(writeWait))
+		s.conn.Close()
+	})
+}
@@ -0,0 +1,85 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/xaitan80/go-server/internal/stream"
+)
+
+// dialSession starts a server running a session for userID and connects to it
+func dialSession(t *testing.T, hub *stream.Hub, userID uuid.UUID, expiresAt time.Time) *websocket.Conn {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		NewSession(conn, hub, userID, expiresAt, "secret").Run()
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestSessionSubscribeAndReceive(t *testing.T) {
+	hub := stream.NewHub(10)
+	userID := uuid.New()
+	conn := dialSession(t, hub, userID, time.Time{})
+
+	conn.WriteJSON(clientMessage{Type: "subscribe", Channel: ChannelNotifications})
+
+	var msg serverMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if msg.Type != "subscribed" || msg.Channel != ChannelNotifications {
+		t.Fatalf("expected subscribed confirmation, got %+v", msg)
+	}
+
+	hub.Publish(stream.Event{ID: 1, Type: "user.upgraded", RecipientID: uuid.New(), Data: []byte(`{}`)})
+	hub.Publish(stream.Event{ID: 2, Type: "user.upgraded", RecipientID: userID, Data: []byte(`{}`)})
+
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if msg.Type != "event" || msg.ID != 2 {
+		t.Errorf("expected own event 2, got %+v", msg)
+	}
+}
+
+func TestSessionRejectsUnknownChannel(t *testing.T) {
+	conn := dialSession(t, stream.NewHub(10), uuid.New(), time.Time{})
+
+	conn.WriteJSON(clientMessage{Type: "subscribe", Channel: "everything"})
+
+	var msg serverMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if msg.Type != "error" {
+		t.Errorf("expected error, got %+v", msg)
+	}
+}
+
+func TestSessionClosesOnTokenExpiry(t *testing.T) {
+	conn := dialSession(t, stream.NewHub(10), uuid.New(), time.Now().Add(50*time.Millisecond))
+
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, CloseTokenExpired) {
+		t.Errorf("expected close code %d, got %v", CloseTokenExpired, err)
+	}
+}
@@ -1,25 +1,56 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
-	"sync/atomic"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/xaitan80/go-server/api"
 	"github.com/xaitan80/go-server/app"
+	"github.com/xaitan80/go-server/internal/accounts"
+	"github.com/xaitan80/go-server/internal/auth"
+	"github.com/xaitan80/go-server/internal/authz"
+	"github.com/xaitan80/go-server/internal/certs"
 	"github.com/xaitan80/go-server/internal/config"
 	"github.com/xaitan80/go-server/internal/database"
+	"github.com/xaitan80/go-server/internal/logging"
+	"github.com/xaitan80/go-server/internal/media"
+	"github.com/xaitan80/go-server/internal/metrics"
+	"github.com/xaitan80/go-server/internal/outbox"
+	"github.com/xaitan80/go-server/internal/ratelimit"
+	"github.com/xaitan80/go-server/internal/retention"
+	"github.com/xaitan80/go-server/internal/scheduler"
+	"github.com/xaitan80/go-server/internal/stream"
+	"github.com/xaitan80/go-server/internal/tracing"
+	"github.com/xaitan80/go-server/internal/websec"
 )
 
 // Middleware that increments the fileserver hit counter
-func middlewareMetricsInc(hits *atomic.Int32, next http.Handler) http.Handler {
+func middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		hits.Add(1)
+		metrics.FileserverHits.Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Serves files without directory listings
+func noDirListing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -36,81 +67,565 @@ func methodHandler(handlers map[string]http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// HTTP server limits
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+	maxHeaderBytes    = 64 << 10
+)
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, relying on OS environment variables")
+	// Defaults, then CONFIG_FILE, .env and the environment; see internal/config
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("invalid configuration", err)
 	}
 
-	const port = "8080"
+	// Structured JSON logs; the standard log package is routed through it too
+	logger := logging.New(os.Stdout, cfg.LogLevel)
+	slog.SetDefault(logger)
+	slog.Info("configuration loaded", "config", cfg)
+
+	// On SIGTERM or SIGINT the server stops accepting connections and has
+	// cfg.ShutdownTimeout to drain requests and background workers
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
 
 	// Database setup
-	dbURL := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbURL)
+	db, err := sql.Open("postgres", cfg.DB.URL)
+	if err != nil {
+		fatal("failed to open DB", err)
+	}
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
+	metrics.RegisterDB(db)
+
+	// Trace exporter: "otlp", "stdout" or "none" (the default)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
 	if err != nil {
-		log.Fatalf("failed to open DB: %v", err)
+		fatal("failed to set up tracing", err)
+	}
+
+	queries := database.New(database.Traced(db))
+
+	// Background workers run until workerCtx is cancelled at shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	// Chirp event hub for streaming clients, fed by Postgres LISTEN/NOTIFY
+	// so every instance sees events relayed by any instance
+	hub := stream.NewHub(1024)
+	runWorker(func(ctx context.Context) {
+		if err := stream.Listen(ctx, cfg.DB.URL, hub); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("stream listener stopped", "error", err)
+		}
+	})
+
+	// Outbox relay: publishes events written alongside chirp and user changes
+	sinks := []outbox.Sink{outbox.LogSink{}, stream.NewNotifySink(db)}
+	if cfg.Outbox.WebhookURL != "" {
+		sinks = append(sinks, outbox.NewHTTPSink(cfg.Outbox.WebhookURL))
 	}
-	defer db.Close()
+	if cfg.Outbox.NATSURL != "" {
+		sinks = append(sinks, outbox.NewNATSSink(cfg.Outbox.NATSURL, "chirpy"))
+	}
+	relay := outbox.NewRelay(db, sinks...)
+	runWorker(relay.Run)
+
+	// Builds the data exports users request
+	runWorker(accounts.NewExporter(queries).Run)
 
-	queries := database.New(db)
+	// Publishes scheduled chirps when they are due
+	runWorker(scheduler.New(db, api.PublishScheduledChirp).Run)
 
-	// Global API config
-	apiCfg := &config.APIConfig{
-		Platform:  os.Getenv("PLATFORM"),
-		JWTSecret: os.Getenv("JWT_SECRET"),
-		PolkaKey:  os.Getenv("POLKA_KEY"),
+	// Media uploads go to an S3-compatible bucket when configured, otherwise
+	// to MEDIA_DIR served under /media/
+	var blobs media.BlobStore
+	var localMediaDir string
+	if cfg.Media.S3Endpoint != "" {
+		blobs = media.NewS3Store(media.S3Config{
+			Endpoint:  cfg.Media.S3Endpoint,
+			Bucket:    cfg.Media.S3Bucket,
+			Region:    cfg.Media.S3Region,
+			AccessKey: cfg.Media.S3AccessKey,
+			SecretKey: cfg.Media.S3SecretKey,
+			PublicURL: cfg.Media.PublicURL,
+		})
+	} else {
+		localMediaDir = cfg.Media.Dir
+		local, err := media.NewLocalStore(localMediaDir, "/media/")
+		if err != nil {
+			fatal("failed to create media directory", err)
+		}
+		blobs = local
 	}
 
-	// Fileserver hit counter
-	var fileserverHits atomic.Int32
+	// Deleted accounts are purged once their grace period has passed
+	runWorker(accounts.NewPurger(db, blobs, cfg.Auth.AccountDeletionGrace).Run)
+
+	// Soft-deleted chirps are purged once their retention period has passed
+	runWorker(retention.NewChirpPurger(queries, blobs, cfg.Chirps.Retention).Run)
+
+	// Uploads never attached to a chirp are removed after a day
+	runWorker(retention.NewMediaSweeper(queries, blobs, retention.DefaultUnattachedMediaTTL).Run)
 
 	mux := http.NewServeMux()
 
 	// --- Admin Endpoints ---
-	mux.Handle("/admin/metrics", api.HitsHandler(&fileserverHits))
-	mux.HandleFunc("/admin/reset", api.ResetHandler(queries, apiCfg.Platform))
+	// Every /admin route requires a role granting the matching permission,
+	// and a verified client certificate when TLS_CLIENT_CA_FILE is set
+	policy := authz.NewPolicy(queries)
+	adminOnly := func(perm string, h http.Handler) http.HandlerFunc {
+		h = api.RequirePermission(policy, cfg.Auth.JWTSecret, perm, h)
+		if cfg.TLS.ClientCAFile != "" {
+			return api.RequireClientCert(h)
+		}
+		return h.ServeHTTP
+	}
+	mux.Handle("/admin/metrics", adminOnly(authz.PermMetricsRead, api.HitsHandler()))
+	mux.Handle("/admin/reset", adminOnly(authz.PermPlatformReset, api.ResetHandler(queries, cfg.Platform)))
+	mux.Handle("/admin/roles", adminOnly(authz.PermRolesManage, api.ListRolesHandler(queries)))
+	mux.Handle("/admin/users/", adminOnly(authz.PermRolesManage, api.SetUserRoleHandler(db)))
+	mux.Handle("/admin/reports", adminOnly(authz.PermReportsReview, api.ListReportsHandler(queries)))
+	mux.Handle("/admin/reports/", adminOnly(authz.PermReportsReview, api.ResolveReportHandler(db, policy, cfg.Auth.JWTSecret)))
+	mux.Handle("/admin/chirps/deleted", adminOnly(authz.PermReportsReview, api.ListDeletedChirpsHandler(queries)))
+	mux.Handle("/admin/moderation/actions", adminOnly(authz.PermReportsReview, api.ListModerationActionsHandler(queries)))
+
+	// --- Prometheus metrics ---
+	// With METRICS_ADDR the scrape endpoint gets its own listener (below);
+	// otherwise it is admin-only like the /admin routes
+	if cfg.Metrics.Addr == "" {
+		mux.Handle("/metrics", adminOnly(authz.PermMetricsRead, metrics.Handler()))
+	}
 
 	// --- Health Endpoint ---
 	mux.HandleFunc("/api/healthz", api.ReadinessHandler)
 
 	// --- Fileserver ---
-	mux.Handle("/app/", middlewareMetricsInc(&fileserverHits, app.FileServerHandler()))
+	mux.Handle("/app/", websec.ContentSecurityPolicy(websec.AppPolicy, middlewareMetricsInc(app.FileServerHandler())))
+
+	if localMediaDir != "" {
+		mux.Handle("/media/", http.StripPrefix("/media/", noDirListing(http.FileServer(http.Dir(localMediaDir)))))
+	}
 
 	// --- API Endpoints ---
 	// /api/chirps handles GET (all) and POST (create)
 	mux.HandleFunc("/api/chirps", methodHandler(map[string]http.HandlerFunc{
-		http.MethodPost: api.ChirpsHandler(queries, apiCfg.JWTSecret),
-		http.MethodGet:  api.GetAllChirpsHandler(queries), // supports author_id + sort
+		http.MethodPost: api.ChirpsHandler(db, queries, cfg.Auth.JWTSecret, cfg.Chirps.MaxLength),
+		http.MethodGet:  api.GetAllChirpsHandler(queries, cfg.Auth.JWTSecret), // supports author_id, sort, cursor + limit
 	}))
 
-	// /api/chirps/{id} for GET single chirp and DELETE chirp
+	// /api/chirps/{id} for GET single chirp and DELETE chirp, /api/chirps/{id}/report|restore|bookmark|pin|poll/votes
 	mux.HandleFunc("/api/chirps/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) == 4 && parts[3] == "report" {
+			api.ReportChirpHandler(queries, cfg.Auth.JWTSecret)(w, r)
+			return
+		}
+		if len(parts) == 4 && parts[3] == "restore" {
+			api.RestoreChirpHandler(db, queries, policy, cfg.Auth.JWTSecret)(w, r)
+			return
+		}
+		if len(parts) == 4 && parts[3] == "bookmark" {
+			api.BookmarkChirpHandler(queries, cfg.Auth.JWTSecret)(w, r)
+			return
+		}
+		if len(parts) == 4 && parts[3] == "pin" {
+			api.PinChirpHandler(db, queries, cfg.Auth.JWTSecret)(w, r)
+			return
+		}
+		if len(parts) == 5 && parts[3] == "poll" && parts[4] == "votes" {
+			api.VotePollHandler(queries, cfg.Auth.JWTSecret)(w, r)
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
-			api.GetChirpHandler(queries)(w, r)
+			api.GetChirpHandler(queries, cfg.Auth.JWTSecret)(w, r)
 		case http.MethodDelete:
-			api.DeleteChirpHandler(queries, apiCfg.JWTSecret)(w, r)
+			api.DeleteChirpHandler(db, queries, cfg.Auth.JWTSecret)(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Method not allowed"})
 		}
 	})
 
+	// /api/media uploads an image to attach to a chirp
+	mux.HandleFunc("/api/media", api.UploadMediaHandler(queries, blobs, cfg.Auth.JWTSecret))
+
+	// /api/bookmarks lists the caller's bookmarked chirps
+	mux.HandleFunc("/api/bookmarks", api.ListBookmarksHandler(queries, cfg.Auth.JWTSecret))
+
+	// /api/drafts lists drafts and scheduled chirps, /api/drafts/{id} edits or cancels one
+	mux.HandleFunc("/api/drafts", api.ListDraftsHandler(queries, cfg.Auth.JWTSecret))
+	mux.HandleFunc("/api/drafts/", methodHandler(map[string]http.HandlerFunc{
+		http.MethodPut:    api.UpdateDraftHandler(db, queries, cfg.Auth.JWTSecret, cfg.Chirps.MaxLength),
+		http.MethodDelete: api.DeleteDraftHandler(queries, blobs, cfg.Auth.JWTSecret),
+	}))
+
+	// /api/stream/chirps pushes chirp events as Server-Sent Events
+	mux.HandleFunc("/api/stream/chirps", api.StreamChirpsHandler(hub))
+
+	// /api/ws is the bidirectional live timeline and notification socket
+	mux.HandleFunc("/api/ws", api.WebSocketHandler(hub, cfg.Auth.JWTSecret))
+
 	// /api/users handles POST (create) and PUT (update)
 	mux.HandleFunc("/api/users", methodHandler(map[string]http.HandlerFunc{
 		http.MethodPost: api.CreateUserHandler(queries),
-		http.MethodPut:  api.UpdateUserHandler(queries, apiCfg.JWTSecret),
+		http.MethodPut:  api.UpdateUserHandler(db, cfg.Auth.JWTSecret),
+	}))
+
+	// /api/users/me, /api/users/{id}, /api/users/by-handle/{handle} and /api/users/{id}/block|mute|follow
+	mux.HandleFunc("/api/users/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case len(parts) == 3 && parts[2] == "me":
+			api.DeleteAccountHandler(db, queries, cfg.Auth.JWTSecret, cfg.Auth.AccountDeletionGrace)(w, r)
+		case len(parts) == 4 && parts[2] == "me" && parts[3] == "export":
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodPost: api.StartExportHandler(queries, cfg.Auth.JWTSecret),
+				http.MethodGet:  api.ExportAccountHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		case len(parts) == 4 && parts[2] == "by-handle":
+			api.GetUserByHandleHandler(queries)(w, r)
+		case len(parts) == 4 && parts[3] == "block":
+			api.BlockUserHandler(db, queries, cfg.Auth.JWTSecret)(w, r)
+		case len(parts) == 4 && parts[3] == "mute":
+			api.MuteUserHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case len(parts) == 4 && parts[3] == "follow":
+			api.FollowUserHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case len(parts) == 3:
+			api.GetUserHandler(queries)(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Not found"})
+		}
+	})
+
+	// /api/notifications lists the caller's notifications
+	mux.HandleFunc("/api/notifications", api.ListNotificationsHandler(queries, cfg.Auth.JWTSecret))
+
+	// /api/notifications/read-all, /preferences and /{id}/read
+	mux.HandleFunc("/api/notifications/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/notifications/read-all":
+			api.MarkAllNotificationsReadHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case r.URL.Path == "/api/notifications/preferences":
+			api.NotificationPreferencesHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case strings.HasSuffix(r.URL.Path, "/read"):
+			api.MarkNotificationReadHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Not found"})
+		}
+	})
+
+	// /api/lists handles POST (create) and GET (owned and subscribed lists)
+	mux.HandleFunc("/api/lists", methodHandler(map[string]http.HandlerFunc{
+		http.MethodPost: api.CreateListHandler(queries, cfg.Auth.JWTSecret),
+		http.MethodGet:  api.ListListsHandler(queries, cfg.Auth.JWTSecret),
 	}))
 
-	// /api/login
-	mux.HandleFunc("/api/login", api.LoginHandler(queries, apiCfg.JWTSecret))
+	// /api/lists/{id}[/members[/{userID}]|/chirps|/subscription]
+	mux.HandleFunc("/api/lists/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		action := ""
+		if len(parts) >= 4 {
+			action = parts[3]
+		}
+		switch {
+		case len(parts) == 3:
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodGet:    api.GetListHandler(queries, cfg.Auth.JWTSecret),
+				http.MethodPut:    api.UpdateListHandler(db, queries, cfg.Auth.JWTSecret),
+				http.MethodDelete: api.DeleteListHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		case len(parts) == 4 && action == "members":
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodGet:  api.ListMembersHandler(queries, cfg.Auth.JWTSecret),
+				http.MethodPost: api.AddListMemberHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		case len(parts) == 5 && action == "members":
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodDelete: api.RemoveListMemberHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		case len(parts) == 4 && action == "chirps":
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodGet: api.ListChirpsHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		case len(parts) == 4 && action == "subscription":
+			methodHandler(map[string]http.HandlerFunc{
+				http.MethodPost:   api.ListSubscriptionHandler(queries, cfg.Auth.JWTSecret),
+				http.MethodDelete: api.ListSubscriptionHandler(queries, cfg.Auth.JWTSecret),
+			})(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Not found"})
+		}
+	})
+
+	// /api/conversations handles POST (start) and GET (list)
+	mux.HandleFunc("/api/conversations", methodHandler(map[string]http.HandlerFunc{
+		http.MethodPost: api.StartConversationHandler(db, queries, cfg.Auth.JWTSecret),
+		http.MethodGet:  api.ListConversationsHandler(queries, cfg.Auth.JWTSecret),
+	}))
+
+	// /api/conversations/{id}[/messages|/read|/leave]
+	mux.HandleFunc("/api/conversations/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		action := ""
+		if len(parts) == 4 {
+			action = parts[3]
+		}
+		switch {
+		case len(parts) == 3:
+			api.GetConversationHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case action == "messages" && r.Method == http.MethodPost:
+			api.SendMessageHandler(db, queries, cfg.Auth.JWTSecret)(w, r)
+		case action == "messages":
+			api.ListMessagesHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case action == "read":
+			api.MarkConversationReadHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		case action == "leave":
+			api.LeaveConversationHandler(queries, cfg.Auth.JWTSecret)(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(api.ErrorResponse{Error: "Not found"})
+		}
+	})
+
+	// Cookie sessions for browsers, when AUTH_COOKIES is set
+	var sessions *websec.Sessions
+	if cfg.Auth.Cookies {
+		sessions = &websec.Sessions{
+			CrossSite: cfg.CORS.AllowCredentials,
+			// Plain HTTP cookies only for local development without TLS
+			Insecure: cfg.Platform == "dev" && !cfg.TLS.Enabled(),
+		}
+	}
+
+	// /api/login, and /api/logout to end a cookie session
+	mux.HandleFunc("/api/login", api.LoginHandler(queries, cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL, sessions))
+	if sessions != nil {
+		mux.HandleFunc("/api/logout", api.LogoutHandler(sessions))
+	}
 
 	// refresh and revoke
-	mux.HandleFunc("/api/refresh", api.RefreshHandler(queries, apiCfg.JWTSecret))
+	mux.HandleFunc("/api/refresh", api.RefreshHandler(queries, cfg.Auth.JWTSecret, cfg.Auth.RefreshedAccessTokenTTL))
 	mux.HandleFunc("/api/revoke", api.RevokeHandler(queries))
 
 	// /api/polka/webhooks
-	mux.HandleFunc("/api/polka/webhooks", api.PolkaWebhooksHandler(queries, apiCfg))
+	mux.HandleFunc("/api/polka/webhooks", api.PolkaWebhooksHandler(db, cfg))
+
+	// authenticatedUser names the user of a request with a valid token in
+	// its Authorization header or session cookie
+	authenticatedUser := func(r *http.Request) string {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil && sessions != nil {
+			token = sessions.Token(r)
+		}
+		userID, err := auth.ValidateJWT(token, cfg.Auth.JWTSecret)
+		if err != nil {
+			return ""
+		}
+		return userID.String()
+	}
+
+	// --- Rate limits ---
+	// Every rate-limited route and its policy; routes sharing a policy share
+	// its buckets. Anonymous requests to per-user routes count per IP.
+	var handler http.Handler = metrics.Middleware(mux)
+	if cfg.RateLimit.Store != "off" {
+		var store ratelimit.Store
+		if cfg.RateLimit.Store == "postgres" {
+			pgStore := ratelimit.NewPostgresStore(db)
+			runWorker(pgStore.Run)
+			store = pgStore
+		} else {
+			memStore := ratelimit.NewMemoryStore()
+			runWorker(memStore.Run)
+			store = memStore
+		}
+		trustedProxies, err := cfg.RateLimit.TrustedProxyPrefixes()
+		if err != nil {
+			fatal("invalid trusted proxies", err)
+		}
+
+		loginPolicy := ratelimit.Policy{Name: "login", Limit: 10, Period: time.Minute, Burst: 5, Scope: ratelimit.PerIP}
+		signupPolicy := ratelimit.Policy{Name: "signup", Limit: 5, Period: time.Hour, Scope: ratelimit.PerIP}
+		tokenPolicy := ratelimit.Policy{Name: "token", Limit: 30, Period: time.Minute, Scope: ratelimit.PerIP}
+		writePolicy := ratelimit.Policy{Name: "write", Limit: 30, Period: time.Minute, Burst: 10, Scope: ratelimit.PerUser}
+		uploadPolicy := ratelimit.Policy{Name: "upload", Limit: 20, Period: time.Hour, Burst: 5, Scope: ratelimit.PerUser}
+
+		limiter := ratelimit.New(store, map[string]ratelimit.Policy{
+			"POST /api/login":                       loginPolicy,
+			"POST /api/users":                       signupPolicy,
+			"POST /api/refresh":                     tokenPolicy,
+			"POST /api/chirps":                      writePolicy,
+			"POST /api/chirps/{id}/report":          writePolicy,
+			"POST /api/conversations":               writePolicy,
+			"POST /api/conversations/{id}/messages": writePolicy,
+			"POST /api/media":                       uploadPolicy,
+		}, authenticatedUser, trustedProxies)
+		handler = limiter.Middleware(handler)
+	}
+
+	// --- Browser clients ---
+	// Session cookies stand in for the Authorization header once their CSRF
+	// token is checked, CORS admits the configured origins and every
+	// response gets the default security headers
+	if sessions != nil {
+		handler = sessions.Middleware(handler)
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		handler = websec.CORS{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}.Middleware(handler)
+	}
+	handler = websec.Headers(handler)
+
+	// Every request gets an X-Request-ID, a structured log line naming the
+	// authenticated user, if any, and a trace span named after its route
+	handler = logging.Middleware(logger, authenticatedUser, tracing.Middleware(handler))
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	// Shutdown doesn't wait for streams or hijacked WebSocket connections;
+	// closing the hub ends them
+	server.RegisterOnShutdown(hub.Close)
+
+	// HTTP/2 is negotiated over TLS, or spoken in plaintext (h2c) with H2C
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetHTTP1(true)
+	if cfg.TLS.Enabled() {
+		server.Protocols.SetHTTP2(true)
+
+		// Certificates are reloaded when the files change or on SIGHUP
+		reloader, err := certs.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			fatal("failed to load TLS certificate", err)
+		}
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		runWorker(func(ctx context.Context) {
+			reloader.Watch(ctx, cfg.TLS.ReloadInterval, hup)
+		})
+
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+		// The handshake can't tell which route is requested, so client
+		// certificates are optional here and enforced on /admin routes
+		if cfg.TLS.ClientCAFile != "" {
+			pool, err := certs.LoadCAPool(cfg.TLS.ClientCAFile)
+			if err != nil {
+				fatal("failed to load client CA", err)
+			}
+			server.TLSConfig.ClientCAs = pool
+			server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	} else if cfg.H2C {
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("serving", "port", cfg.Port, "tls", cfg.TLS.Enabled(), "h2c", cfg.H2C)
+		if cfg.TLS.Enabled() {
+			serveErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	// The metrics listener is plain HTTP for the scraper and should not be
+	// exposed publicly
+	var metricsServer *http.Server
+	if cfg.Metrics.Addr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       readTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+		go func() {
+			slog.Info("serving metrics", "addr", cfg.Metrics.Addr)
+			serveErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		fatal("server stopped", err)
+	case <-signalCtx.Done():
+		// A second signal kills the process right away
+		stopSignals()
+	}
+
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, mux))
+	// Stop accepting connections and drain in-flight requests, then stop the
+	// workers so nothing uses the database once the pool closes
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to drain requests", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to stop metrics listener", "error", err)
+		}
+	}
+	stopWorkers()
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		slog.Error("background workers did not stop in time")
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close DB", "error", err)
+	}
+	slog.Info("shutdown complete")
 }
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    topic TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP
+);
+
+CREATE INDEX outbox_events_pending_idx
+ON outbox_events (available_at, id)
+WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE outbox_events;
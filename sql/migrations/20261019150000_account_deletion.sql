@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN deletion_requested_at TIMESTAMP;
+
+CREATE INDEX users_deletion_requested_at_idx ON users (deletion_requested_at)
+WHERE deletion_requested_at IS NOT NULL;
+
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    archive BYTEA,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_user_created_idx ON data_exports (user_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE data_exports;
+
+DROP INDEX users_deletion_requested_at_idx;
+
+ALTER TABLE users
+DROP COLUMN deletion_requested_at;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Regular account'),
+    ('moderator', 'Reviews reported content'),
+    ('admin', 'Full access to /admin and role management');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'metrics:read'),
+    ('admin', 'platform:reset'),
+    ('admin', 'roles:manage');
+
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user' REFERENCES roles(name);
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN role;
+
+DROP TABLE role_permissions;
+DROP TABLE roles;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published')),
+ADD COLUMN scheduled_at TIMESTAMP,
+ADD CONSTRAINT chirps_scheduled_at_check CHECK (status <> 'scheduled' OR scheduled_at IS NOT NULL);
+
+CREATE INDEX chirps_due_idx ON chirps (scheduled_at)
+WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX chirps_due_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_scheduled_at_check,
+DROP COLUMN status,
+DROP COLUMN scheduled_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    url TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    position INTEGER
+);
+
+CREATE INDEX media_chirp_idx ON media (chirp_id, position)
+WHERE chirp_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE media;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_created_idx ON bookmarks (user_id, created_at DESC, chirp_id DESC);
+
+ALTER TABLE chirps
+ADD COLUMN pinned_at TIMESTAMP;
+
+CREATE INDEX chirps_pinned_idx ON chirps (user_id, pinned_at DESC)
+WHERE pinned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_pinned_idx;
+
+ALTER TABLE chirps
+DROP COLUMN pinned_at;
+
+DROP TABLE bookmarks;
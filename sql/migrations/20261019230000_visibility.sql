@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id);
+
+-- Users mentioned in a chirp, who can see it whatever its visibility
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_mentions_user_idx ON chirp_mentions (user_id);
+
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN visibility;
+
+DROP TABLE chirp_mentions;
+DROP TABLE follows;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_private BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX lists_owner_idx ON lists (owner_id);
+
+CREATE TABLE list_members (
+    list_id UUID NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE TABLE list_subscriptions (
+    list_id UUID NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX list_subscriptions_user_idx ON list_subscriptions (user_id);
+
+-- +goose Down
+DROP TABLE list_subscriptions;
+DROP TABLE list_members;
+DROP TABLE lists;
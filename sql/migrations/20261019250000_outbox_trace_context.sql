@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE outbox_events
+ADD COLUMN traceparent TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE outbox_events
+DROP COLUMN traceparent;
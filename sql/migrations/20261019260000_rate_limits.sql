@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX rate_limit_buckets_updated_idx ON rate_limit_buckets (updated_at);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
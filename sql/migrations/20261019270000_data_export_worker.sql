@@ -0,0 +1,25 @@
+-- +goose Up
+-- Keep only the newest pending export per user before enforcing it
+UPDATE data_exports
+SET status = 'failed',
+    error = 'superseded by a newer export',
+    completed_at = NOW()
+WHERE status = 'pending'
+  AND id NOT IN (
+    SELECT DISTINCT ON (user_id) id
+    FROM data_exports
+    WHERE status = 'pending'
+    ORDER BY user_id, created_at DESC
+  );
+
+ALTER TABLE data_exports
+ADD COLUMN started_at TIMESTAMP;
+
+CREATE UNIQUE INDEX data_exports_pending_user_idx ON data_exports (user_id)
+WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX data_exports_pending_user_idx;
+
+ALTER TABLE data_exports
+DROP COLUMN started_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN publish_attempts INT NOT NULL DEFAULT 0,
+ADD COLUMN publish_error TEXT,
+ADD COLUMN publish_retry_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN publish_retry_at,
+DROP COLUMN publish_error,
+DROP COLUMN publish_attempts;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX outbox_events_published_idx
+ON outbox_events (published_at)
+WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX outbox_events_published_idx;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE billing_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX billing_events_user_created_idx ON billing_events (user_id, created_at);
+
+-- Carry over upgrades that were only recorded in the outbox
+INSERT INTO billing_events (user_id, event, details, created_at)
+SELECT o.aggregate_id, o.topic, o.payload, o.created_at
+FROM outbox_events o
+JOIN users u ON u.id = o.aggregate_id
+WHERE o.topic = 'user.upgraded';
+
+-- +goose Down
+DROP TABLE billing_events;
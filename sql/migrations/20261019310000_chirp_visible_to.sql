@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Whether viewer can see a chirp: public chirps, their own, followers-only
+-- chirps of people they follow and chirps mentioning them, unless either
+-- side has blocked the other. A NULL viewer sees public chirps only.
+CREATE FUNCTION chirp_visible_to(target_chirp UUID, target_author UUID, target_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(
+        (
+            target_visibility = 'public'
+            OR target_author = viewer
+            OR (
+                target_visibility = 'followers'
+                AND EXISTS (
+                    SELECT 1
+                    FROM follows f
+                    WHERE f.follower_id = viewer
+                      AND f.followee_id = target_author
+                )
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM chirp_mentions cm
+                WHERE cm.chirp_id = target_chirp
+                  AND cm.user_id = viewer
+            )
+        )
+        AND (
+            viewer IS NULL
+            OR NOT EXISTS (
+                SELECT 1
+                FROM user_blocks b
+                WHERE (b.blocker_id = viewer AND b.blocked_id = target_author)
+                   OR (b.blocker_id = target_author AND b.blocked_id = viewer)
+            )
+        ),
+        FALSE
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to(UUID, UUID, TEXT, UUID);
@@ -11,11 +11,12 @@ SELECT *
 FROM users
 WHERE email = $1;
 
--- name: UpgradeUserToChirpyRed :exec
+-- name: UpgradeUserToChirpyRed :execrows
 UPDATE users
 SET is_chirpy_red = TRUE,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1
+  AND NOT is_chirpy_red;
 
 -- name: GetUserFromRefreshToken :one
 SELECT 
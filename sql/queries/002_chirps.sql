@@ -1,22 +1,29 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id, author_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, author_id, reply_to_id, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $2, $3, $4)
 RETURNING *;
 
 -- name: GetChirpByID :one
 SELECT *
 FROM chirps
-WHERE id = $1;
+WHERE id = $1
+  AND status = 'published'
+  AND deleted_at IS NULL;
 
 -- name: GetAllChirps :many
 SELECT *
 FROM chirps
+WHERE status = 'published'
+  AND deleted_at IS NULL
+  AND visibility = 'public'
 ORDER BY created_at ASC;
 
 -- name: GetChirpsByAuthorID :many
 SELECT *
 FROM chirps
 WHERE author_id = $1
+  AND status = 'published'
+  AND deleted_at IS NULL
 ORDER BY created_at ASC;
 
 -- name: DeleteAllChirps :exec
@@ -25,4 +32,7 @@ DELETE FROM chirps;
 -- name: ListChirps :many
 SELECT *
 FROM chirps
+WHERE status = 'published'
+  AND deleted_at IS NULL
+  AND visibility = 'public'
 ORDER BY created_at DESC;
\ No newline at end of file
@@ -1,3 +1,6 @@
 -- name: DeleteChirp :exec
-DELETE FROM chirps
-WHERE id = $1;
+UPDATE chirps
+SET deleted_at = NOW(),
+    deleted_by = $2
+WHERE id = $1
+  AND deleted_at IS NULL;
@@ -1,9 +1,8 @@
 -- name: UpdateUser :one
 UPDATE users
 SET 
-    email = $2,
-    hashed_password = $3,
-    is_chirpy_red = $4,
+    email = COALESCE(sqlc.narg(email), email),
+    hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg(id)
 RETURNING *;
@@ -0,0 +1,51 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (topic, aggregate_id, payload, traceparent)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET available_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+    SELECT id
+    FROM outbox_events
+    WHERE published_at IS NULL
+      AND available_at <= NOW()
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = NOW(),
+    last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+    last_error = sqlc.arg(last_error),
+    available_at = NOW() + make_interval(secs => sqlc.arg(retry_after_seconds)::float8)
+WHERE id = sqlc.arg(id);
+
+-- name: DeletePublishedOutboxEvents :execrows
+-- Deletes up to batch_size events published more than retention_seconds ago
+DELETE FROM outbox_events
+WHERE id IN (
+    SELECT id
+    FROM outbox_events
+    WHERE published_at < NOW() - make_interval(secs => sqlc.arg(retention_seconds)::float8)
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: DeleteUserOutboxEvents :execrows
+-- Deletes every event about the user or carrying their data: events on the
+-- user themselves, on chirps they wrote and notifications they received or
+-- caused
+DELETE FROM outbox_events
+WHERE aggregate_id = sqlc.arg(user_id)::uuid
+   OR payload->>'user_id' = sqlc.arg(user_id)::uuid::text
+   OR payload->>'actor_id' = sqlc.arg(user_id)::uuid::text;
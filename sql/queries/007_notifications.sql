@@ -0,0 +1,58 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, type, actor_id, chirp_id)
+SELECT sqlc.arg(user_id), sqlc.arg(type), sqlc.narg(actor_id), sqlc.narg(chirp_id)
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM notification_preferences p
+    WHERE p.user_id = sqlc.arg(user_id)
+      AND p.type = sqlc.arg(type)
+      AND NOT p.enabled
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL)
+  AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid)
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: ListNotificationPreferences :many
+SELECT *
+FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled;
+
+-- name: GetUserIDsByEmails :many
+SELECT id
+FROM users
+WHERE email = ANY(sqlc.arg(emails)::text[]);
@@ -0,0 +1,100 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (created_by)
+VALUES ($1)
+RETURNING *;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id)
+VALUES ($1, $2);
+
+-- name: GetConversationForMember :one
+SELECT c.*
+FROM conversations c
+JOIN conversation_members m ON m.conversation_id = c.id
+WHERE c.id = $1
+  AND m.user_id = $2
+  AND m.left_at IS NULL;
+
+-- name: FindDirectConversation :one
+SELECT c.*
+FROM conversations c
+WHERE (SELECT COUNT(*) FROM conversation_members m WHERE m.conversation_id = c.id) = 2
+  AND EXISTS (
+    SELECT 1 FROM conversation_members m
+    WHERE m.conversation_id = c.id AND m.user_id = sqlc.arg(user_a) AND m.left_at IS NULL
+  )
+  AND EXISTS (
+    SELECT 1 FROM conversation_members m
+    WHERE m.conversation_id = c.id AND m.user_id = sqlc.arg(user_b) AND m.left_at IS NULL
+  )
+LIMIT 1;
+
+-- name: ListConversationMembers :many
+SELECT *
+FROM conversation_members
+WHERE conversation_id = $1
+  AND left_at IS NULL
+ORDER BY joined_at, user_id;
+
+-- name: ListConversationsForUser :many
+SELECT
+    c.id,
+    c.created_at,
+    c.updated_at,
+    c.created_by,
+    (
+        SELECT COUNT(*)
+        FROM messages msg
+        WHERE msg.conversation_id = c.id
+          AND msg.sender_id <> m.user_id
+          AND (m.last_read_at IS NULL OR msg.created_at > m.last_read_at)
+    ) AS unread_count
+FROM conversations c
+JOIN conversation_members m ON m.conversation_id = c.id
+WHERE m.user_id = sqlc.arg(user_id)
+  AND m.left_at IS NULL
+  AND (
+    sqlc.narg(before_updated_at)::timestamp IS NULL
+    OR (c.updated_at, c.id) < (sqlc.narg(before_updated_at)::timestamp, sqlc.narg(before_id)::uuid)
+  )
+ORDER BY c.updated_at DESC, c.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, body)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListMessages :many
+SELECT *
+FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+  AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid)
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2;
+
+-- name: LeaveConversation :execrows
+UPDATE conversation_members
+SET left_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2
+  AND left_at IS NULL;
+
+-- name: CountUsersByIDs :one
+SELECT COUNT(*)
+FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
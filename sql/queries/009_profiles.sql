@@ -0,0 +1,32 @@
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg(handle));
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = COALESCE(sqlc.narg(handle), handle),
+    display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url),
+    location = COALESCE(sqlc.narg(location), location),
+    website = COALESCE(sqlc.narg(website), website),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetUserHandlesByIDs :many
+SELECT id, handle
+FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+  AND handle IS NOT NULL;
+
+-- name: GetUserIDsByHandles :many
+SELECT id
+FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg(handles)::text[]);
@@ -0,0 +1,38 @@
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: MuteUser :exec
+INSERT INTO user_mutes (muter_id, muted_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM user_mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: HasBlockBetweenAny :one
+SELECT EXISTS (
+    SELECT 1
+    FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = ANY(sqlc.arg(other_ids)::uuid[]))
+       OR (blocked_id = sqlc.arg(user_id) AND blocker_id = ANY(sqlc.arg(other_ids)::uuid[]))
+);
+
+-- name: HasBlockInConversation :one
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_members m
+    JOIN user_blocks b
+      ON (b.blocker_id = m.user_id AND b.blocked_id = sqlc.arg(user_id))
+      OR (b.blocked_id = m.user_id AND b.blocker_id = sqlc.arg(user_id))
+    WHERE m.conversation_id = sqlc.arg(conversation_id)
+      AND m.left_at IS NULL
+);
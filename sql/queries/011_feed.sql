@@ -0,0 +1,38 @@
+-- name: ListFeedChirps :many
+SELECT c.*
+FROM chirps c
+WHERE c.status = 'published'
+  AND c.hidden_at IS NULL
+  AND c.deleted_at IS NULL
+  AND (sqlc.narg(author_id)::uuid IS NULL OR c.author_id = sqlc.narg(author_id)::uuid)
+  AND (
+    sqlc.narg(list_id)::uuid IS NULL
+    OR c.author_id IN (
+      SELECT lm.user_id
+      FROM list_members lm
+      WHERE lm.list_id = sqlc.narg(list_id)::uuid
+    )
+  )
+  AND chirp_visible_to(c.id, c.author_id, c.visibility, sqlc.narg(viewer_id)::uuid)
+  AND (
+    sqlc.narg(viewer_id)::uuid IS NULL
+    OR sqlc.narg(author_id)::uuid IS NOT NULL
+    OR NOT EXISTS (
+      SELECT 1
+      FROM user_mutes m
+      WHERE m.muter_id = sqlc.narg(viewer_id)::uuid
+        AND m.muted_id = c.author_id
+    )
+  )
+  AND (NOT sqlc.arg(exclude_pinned)::boolean OR c.pinned_at IS NULL)
+  AND (
+    sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (sqlc.arg(sort_desc)::boolean AND (c.created_at, c.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+    OR (NOT sqlc.arg(sort_desc)::boolean AND (c.created_at, c.id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+  )
+ORDER BY
+  CASE WHEN sqlc.arg(sort_desc)::boolean THEN c.created_at END DESC,
+  CASE WHEN sqlc.arg(sort_desc)::boolean THEN c.id END DESC,
+  c.created_at ASC,
+  c.id ASC
+LIMIT sqlc.narg(row_limit);
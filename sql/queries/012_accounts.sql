@@ -0,0 +1,94 @@
+-- name: RequestUserDeletion :one
+UPDATE users
+SET deletion_requested_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET deletion_requested_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: PurgeDeletedUsers :many
+-- Returns the purged users with the storage keys of their uploads, read in
+-- the same statement because the media rows are deleted with the users
+WITH purged AS (
+    DELETE FROM users
+    WHERE deletion_requested_at IS NOT NULL
+      AND deletion_requested_at < sqlc.arg(cutoff)::timestamp
+    RETURNING id
+)
+SELECT p.id,
+       array_remove(array_agg(m.storage_key) || array_agg(m.thumbnail_key), NULL)::text[] AS blob_keys
+FROM purged p
+LEFT JOIN media m ON m.user_id = p.id
+GROUP BY p.id;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
+
+-- name: ListUserRefreshTokens :many
+SELECT *
+FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY expires_at DESC;
+
+-- name: CreateBillingEvent :exec
+INSERT INTO billing_events (user_id, event, details)
+VALUES ($1, $2, $3);
+
+-- name: ListUserBillingEvents :many
+SELECT *
+FROM billing_events
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: CreateDataExport :one
+-- Returns sql.ErrNoRows if the user already has a pending export
+INSERT INTO data_exports (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+RETURNING *;
+
+-- name: ClaimDataExport :one
+-- Claims the oldest pending export that no worker is building. A claim
+-- lapses after lease_seconds so exports lost in a crash are built again.
+UPDATE data_exports
+SET started_at = NOW()
+WHERE id = (
+    SELECT id
+    FROM data_exports
+    WHERE status = 'pending'
+      AND (started_at IS NULL OR started_at < NOW() - make_interval(secs => sqlc.arg(lease_seconds)::float8))
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetLatestDataExport :one
+SELECT *
+FROM data_exports
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready',
+    archive = $2,
+    error = NULL,
+    completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed',
+    error = $2,
+    completed_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,31 @@
+-- name: ListRoles :many
+SELECT *
+FROM roles
+ORDER BY name;
+
+-- name: ListRolePermissions :many
+SELECT permission
+FROM role_permissions
+WHERE role = $1
+ORDER BY permission;
+
+-- name: UserHasPermission :one
+SELECT EXISTS (
+    SELECT 1
+    FROM users u
+    JOIN role_permissions rp ON rp.role = u.role
+    WHERE u.id = sqlc.arg(user_id)
+      AND rp.permission = sqlc.arg(permission)
+);
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CountUsersWithRole :one
+SELECT COUNT(*)
+FROM users
+WHERE role = $1;
@@ -0,0 +1,66 @@
+-- name: CreateChirpReport :one
+INSERT INTO chirp_reports (chirp_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetChirpReport :one
+SELECT *
+FROM chirp_reports
+WHERE id = $1;
+
+-- name: ListOpenReports :many
+SELECT
+    r.id,
+    r.created_at,
+    r.chirp_id,
+    r.reporter_id,
+    r.reason,
+    r.details,
+    c.body AS chirp_body,
+    c.user_id AS author_id,
+    c.hidden_at,
+    (
+        SELECT COUNT(*)
+        FROM chirp_reports o
+        WHERE o.chirp_id = r.chirp_id
+          AND o.resolved_at IS NULL
+    ) AS open_reports
+FROM chirp_reports r
+JOIN chirps c ON c.id = r.chirp_id
+WHERE r.resolved_at IS NULL
+  AND (
+    sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (r.created_at, r.id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+  )
+ORDER BY r.created_at ASC, r.id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ResolveChirpReports :exec
+UPDATE chirp_reports
+SET resolved_at = NOW()
+WHERE chirp_id = $1
+  AND resolved_at IS NULL;
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_until = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (moderator_id, action, chirp_id, chirp_body, target_user_id, reason, suspended_until)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT *
+FROM moderation_actions
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
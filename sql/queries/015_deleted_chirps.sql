@@ -0,0 +1,39 @@
+-- name: GetChirpByIDWithDeleted :one
+SELECT *
+FROM chirps
+WHERE id = $1;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL,
+    deleted_by = NULL
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: ListDeletedChirps :many
+SELECT *
+FROM chirps
+WHERE deleted_at IS NOT NULL
+  AND (sqlc.narg(author_id)::uuid IS NULL OR author_id = sqlc.narg(author_id)::uuid)
+  AND (
+    sqlc.narg(cursor_deleted_at)::timestamp IS NULL
+    OR (deleted_at, id) < (sqlc.narg(cursor_deleted_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+  )
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: PurgeDeletedChirps :many
+-- Returns the purged chirps with the storage keys of their media, read in
+-- the same statement because the media rows are deleted with the chirps
+WITH purged AS (
+    DELETE FROM chirps
+    WHERE deleted_at IS NOT NULL
+      AND deleted_at < sqlc.arg(cutoff)::timestamp
+    RETURNING id
+)
+SELECT p.id,
+       array_remove(array_agg(m.storage_key) || array_agg(m.thumbnail_key), NULL)::text[] AS blob_keys
+FROM purged p
+LEFT JOIN media m ON m.chirp_id = p.id
+GROUP BY p.id;
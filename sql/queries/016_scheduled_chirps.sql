@@ -0,0 +1,98 @@
+-- name: CreateUnpublishedChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, author_id, reply_to_id, status, scheduled_at, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListUnpublishedChirps :many
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+  AND status <> 'published'
+  AND deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY COALESCE(scheduled_at, created_at) ASC, id ASC;
+
+-- name: GetUnpublishedChirp :one
+SELECT *
+FROM chirps
+WHERE id = $1
+  AND user_id = $2
+  AND status <> 'published'
+  AND deleted_at IS NULL;
+
+-- name: UpdateUnpublishedChirp :one
+UPDATE chirps
+SET body = $3,
+    status = $4,
+    scheduled_at = $5,
+    publish_attempts = 0,
+    publish_error = NULL,
+    publish_retry_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND status <> 'published'
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteUnpublishedChirp :one
+-- Returns the storage keys of the draft's media, which is deleted with it
+WITH deleted AS (
+    DELETE FROM chirps
+    WHERE chirps.id = $1
+      AND chirps.user_id = $2
+      AND status <> 'published'
+    RETURNING chirps.id
+)
+SELECT array_remove(array_agg(m.storage_key) || array_agg(m.thumbnail_key), NULL)::text[] AS blob_keys
+FROM deleted d
+LEFT JOIN media m ON m.chirp_id = d.id
+GROUP BY d.id;
+
+-- name: ListDueChirpIDs :many
+SELECT c.id
+FROM chirps c
+WHERE c.status = 'scheduled'
+  AND c.scheduled_at <= NOW()
+  AND (c.publish_retry_at IS NULL OR c.publish_retry_at <= NOW())
+  AND c.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users u
+    WHERE u.id = c.user_id
+      AND u.suspended_until > NOW()
+  )
+ORDER BY c.scheduled_at
+LIMIT $1;
+
+-- name: ClaimDueChirp :one
+-- Locks a chirp listed by ListDueChirpIDs if it is still due and no other
+-- instance is publishing it
+SELECT *
+FROM chirps
+WHERE id = $1
+  AND status = 'scheduled'
+  AND scheduled_at <= NOW()
+  AND deleted_at IS NULL
+FOR UPDATE SKIP LOCKED;
+
+-- name: FailScheduledChirp :one
+-- Records a failed publish. After max_attempts the chirp goes back to
+-- being a draft, keeping the error for its author.
+UPDATE chirps
+SET publish_attempts = publish_attempts + 1,
+    publish_error = sqlc.arg(publish_error),
+    publish_retry_at = NOW() + make_interval(secs => sqlc.arg(retry_after_seconds)::float8),
+    status = CASE WHEN publish_attempts + 1 >= sqlc.arg(max_attempts)::int THEN 'draft' ELSE status END
+WHERE id = sqlc.arg(id)
+  AND status = 'scheduled'
+RETURNING status;
+
+-- name: PublishChirp :one
+UPDATE chirps
+SET status = 'published',
+    created_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND status <> 'published'
+RETURNING *;
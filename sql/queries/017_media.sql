@@ -0,0 +1,33 @@
+-- name: CreateMedia :one
+INSERT INTO media (user_id, content_type, size_bytes, width, height, storage_key, url, thumbnail_key, thumbnail_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: AttachMediaToChirp :execrows
+UPDATE media
+SET chirp_id = sqlc.arg(chirp_id)::uuid,
+    position = array_position(sqlc.arg(media_ids)::uuid[], id)
+WHERE id = ANY(sqlc.arg(media_ids)::uuid[])
+  AND user_id = sqlc.arg(user_id)
+  AND chirp_id IS NULL;
+
+-- name: ListMediaForChirps :many
+SELECT *
+FROM media
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteUnattachedMedia :many
+-- Deletes uploads never attached to a chirp within ttl_seconds and returns
+-- their storage keys
+DELETE FROM media
+WHERE id IN (
+    SELECT id
+    FROM media
+    WHERE chirp_id IS NULL
+      AND created_at < NOW() - make_interval(secs => sqlc.arg(ttl_seconds)::float8)
+    ORDER BY created_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING storage_key, thumbnail_key;
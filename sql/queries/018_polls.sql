@@ -0,0 +1,49 @@
+-- name: CreatePoll :one
+INSERT INTO polls (chirp_id, closes_at, hide_results)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (poll_id, position, label)
+SELECT sqlc.arg(poll_id), o.position, o.label
+FROM unnest(sqlc.arg(labels)::text[]) WITH ORDINALITY AS o(label, position);
+
+-- name: GetPollByChirpID :one
+SELECT *
+FROM polls
+WHERE chirp_id = $1;
+
+-- name: ListPollsForChirps :many
+SELECT p.*, c.user_id AS author_id
+FROM polls p
+JOIN chirps c ON c.id = p.chirp_id
+WHERE p.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: ListPollOptionTallies :many
+SELECT
+    o.id,
+    o.poll_id,
+    o.position,
+    o.label,
+    COUNT(v.option_id) AS votes
+FROM poll_options o
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE o.poll_id = ANY(sqlc.arg(poll_ids)::uuid[])
+GROUP BY o.id
+ORDER BY o.poll_id, o.position;
+
+-- name: ListUserPollVotes :many
+SELECT poll_id, option_id
+FROM poll_votes
+WHERE user_id = sqlc.arg(user_id)
+  AND poll_id = ANY(sqlc.arg(poll_ids)::uuid[]);
+
+-- name: CastPollVote :one
+INSERT INTO poll_votes (poll_id, option_id, user_id)
+SELECT p.id, o.id, sqlc.arg(user_id)
+FROM polls p
+JOIN poll_options o ON o.poll_id = p.id
+WHERE p.id = sqlc.arg(poll_id)
+  AND o.id = sqlc.arg(option_id)
+  AND p.closes_at > NOW()
+RETURNING *;
@@ -0,0 +1,76 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: ListBookmarkedChirps :many
+SELECT c.*, b.created_at AS bookmarked_at
+FROM bookmarks b
+JOIN chirps c ON c.id = b.chirp_id
+WHERE b.user_id = sqlc.arg(user_id)
+  AND c.status = 'published'
+  AND c.hidden_at IS NULL
+  AND c.deleted_at IS NULL
+  AND chirp_visible_to(c.id, c.author_id, c.visibility, sqlc.arg(user_id))
+  AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (b.created_at, c.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid)
+  )
+ORDER BY b.created_at DESC, c.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: LockUserPins :exec
+-- Serialises pin changes for a user until the end of the transaction, so
+-- concurrent pins can't both pass the limit check in PinChirp
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: PinChirp :one
+-- Pins a chirp if the user is under max_pinned, not counting the chirp
+-- itself or pinned chirps nobody can see. Pinning an already pinned chirp
+-- keeps its original pinned_at. Run after LockUserPins.
+UPDATE chirps
+SET pinned_at = COALESCE(chirps.pinned_at, NOW())
+WHERE chirps.id = sqlc.arg(id)
+  AND chirps.user_id = sqlc.arg(user_id)
+  AND chirps.status = 'published'
+  AND chirps.hidden_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (
+    chirps.pinned_at IS NOT NULL
+    OR (
+      SELECT COUNT(*)
+      FROM chirps p
+      WHERE p.user_id = sqlc.arg(user_id)
+        AND p.id <> sqlc.arg(id)
+        AND p.pinned_at IS NOT NULL
+        AND p.hidden_at IS NULL
+        AND p.deleted_at IS NULL
+    ) < sqlc.arg(max_pinned)::int
+  )
+RETURNING *;
+
+-- name: UnpinChirp :execrows
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+  AND user_id = $2
+  AND pinned_at IS NOT NULL;
+
+-- name: ListPinnedChirps :many
+SELECT c.*
+FROM chirps c
+WHERE c.author_id = sqlc.arg(author_id)
+  AND c.pinned_at IS NOT NULL
+  AND c.status = 'published'
+  AND c.hidden_at IS NULL
+  AND c.deleted_at IS NULL
+  AND chirp_visible_to(c.id, c.author_id, c.visibility, sqlc.narg(viewer_id)::uuid)
+ORDER BY c.pinned_at DESC;
@@ -0,0 +1,28 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1
+  AND followee_id = $2;
+
+-- name: DeleteFollowsBetween :exec
+-- Removes follows in both directions, used when one user blocks the other
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_id) AND followee_id = sqlc.arg(other_id))
+   OR (follower_id = sqlc.arg(other_id) AND followee_id = sqlc.arg(user_id));
+
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: CanViewChirp :one
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps c
+    WHERE c.id = sqlc.arg(id)
+      AND chirp_visible_to(c.id, c.author_id, c.visibility, sqlc.narg(viewer_id)::uuid)
+);
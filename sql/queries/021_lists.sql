@@ -0,0 +1,75 @@
+-- name: CreateList :one
+INSERT INTO lists (owner_id, name, description, is_private)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetList :one
+SELECT *
+FROM lists
+WHERE id = $1;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $3,
+    description = $4,
+    is_private = $5,
+    updated_at = NOW()
+WHERE id = $1
+  AND owner_id = $2
+RETURNING *;
+
+-- name: DeleteListSubscriptions :exec
+DELETE FROM list_subscriptions
+WHERE list_id = $1;
+
+-- name: DeleteList :execrows
+DELETE FROM lists
+WHERE id = $1
+  AND owner_id = $2;
+
+-- name: ListUserLists :many
+SELECT l.*, (l.owner_id <> sqlc.arg(user_id)) AS subscribed
+FROM lists l
+WHERE l.owner_id = sqlc.arg(user_id)
+   OR (
+     NOT l.is_private
+     AND EXISTS (
+       SELECT 1
+       FROM list_subscriptions s
+       WHERE s.list_id = l.id
+         AND s.user_id = sqlc.arg(user_id)
+     )
+   )
+ORDER BY l.created_at, l.id;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1
+  AND user_id = $2;
+
+-- name: CountListMembers :one
+SELECT COUNT(*)
+FROM list_members
+WHERE list_id = $1;
+
+-- name: ListListMembers :many
+SELECT u.id, u.handle, u.display_name, m.created_at AS added_at
+FROM list_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.list_id = $1
+ORDER BY m.created_at, u.id;
+
+-- name: SubscribeToList :exec
+INSERT INTO list_subscriptions (list_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnsubscribeFromList :exec
+DELETE FROM list_subscriptions
+WHERE list_id = $1
+  AND user_id = $2;
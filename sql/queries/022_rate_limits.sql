@@ -0,0 +1,35 @@
+-- name: CreateRateLimitBucket :exec
+-- Starts a full bucket for a new key. Run before LockRateLimitBucket so the
+-- first concurrent requests for a key all queue on the same row.
+INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+VALUES (sqlc.arg(key), sqlc.arg(burst)::float8, statement_timestamp())
+ON CONFLICT (key) DO NOTHING;
+
+-- name: LockRateLimitBucket :exec
+SELECT key
+FROM rate_limit_buckets
+WHERE key = $1
+FOR UPDATE;
+
+-- name: TakeRateLimitToken :one
+-- Refills the bucket for the time since it was last used and takes a token
+-- if one is available. Run after LockRateLimitBucket, which makes this
+-- statement see the latest tokens and timestamp.
+WITH bucket AS (
+    SELECT LEAST(
+        sqlc.arg(burst)::float8,
+        tokens + GREATEST(EXTRACT(EPOCH FROM statement_timestamp()::timestamp - updated_at)::float8, 0) * sqlc.arg(rate)::float8
+    ) AS tokens
+    FROM rate_limit_buckets
+    WHERE key = sqlc.arg(key)
+)
+UPDATE rate_limit_buckets
+SET tokens = CASE WHEN bucket.tokens >= 1 THEN bucket.tokens - 1 ELSE bucket.tokens END,
+    updated_at = statement_timestamp()
+FROM bucket
+WHERE rate_limit_buckets.key = sqlc.arg(key)
+RETURNING (bucket.tokens >= 1)::boolean AS allowed, rate_limit_buckets.tokens;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < NOW() - make_interval(secs => sqlc.arg(idle_seconds)::float8);
@@ -4,5 +4,19 @@ CREATE TABLE users (
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     email TEXT NOT NULL UNIQUE,
     hashed_password TEXT,
-    is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE
+    is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE,
+    handle TEXT,
+    display_name TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    website TEXT NOT NULL DEFAULT '',
+    deletion_requested_at TIMESTAMP,
+    role TEXT NOT NULL DEFAULT 'user' REFERENCES roles(name),
+    suspended_until TIMESTAMP
 );
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (LOWER(handle));
+
+CREATE INDEX users_deletion_requested_at_idx ON users (deletion_requested_at)
+WHERE deletion_requested_at IS NOT NULL;
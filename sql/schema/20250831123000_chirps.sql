@@ -4,5 +4,26 @@ CREATE TABLE chirps (
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     body TEXT NOT NULL,
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    author_id UUID NOT NULL REFERENCES users(id)
+    author_id UUID NOT NULL REFERENCES users(id),
+    reply_to_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    hidden_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    deleted_by UUID,
+    status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published')),
+    scheduled_at TIMESTAMP,
+    pinned_at TIMESTAMP,
+    visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'mentioned')),
+    publish_attempts INT NOT NULL DEFAULT 0,
+    publish_error TEXT,
+    publish_retry_at TIMESTAMP,
+    CONSTRAINT chirps_scheduled_at_check CHECK (status <> 'scheduled' OR scheduled_at IS NOT NULL)
 );
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at)
+WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX chirps_due_idx ON chirps (scheduled_at)
+WHERE status = 'scheduled';
+
+CREATE INDEX chirps_pinned_idx ON chirps (user_id, pinned_at DESC)
+WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    archive BYTEA,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    started_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_user_created_idx ON data_exports (user_id, created_at DESC);
+
+-- A user has at most one export being built
+CREATE UNIQUE INDEX data_exports_pending_user_idx ON data_exports (user_id)
+WHERE status = 'pending';
@@ -0,0 +1,27 @@
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP,
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE INDEX chirp_reports_open_idx ON chirp_reports (created_at, id)
+WHERE resolved_at IS NULL;
+
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend')),
+    chirp_id UUID,
+    chirp_body TEXT NOT NULL DEFAULT '',
+    target_user_id UUID NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    suspended_until TIMESTAMP
+);
+
+CREATE INDEX moderation_actions_created_idx ON moderation_actions (created_at DESC, id DESC);
@@ -0,0 +1,27 @@
+CREATE TABLE polls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    chirp_id UUID NOT NULL UNIQUE REFERENCES chirps(id) ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL,
+    hide_results BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE poll_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- Votes outlive their voters (user_id becomes NULL) so closed results stay
+-- frozen; the unique constraint allows one vote per user per poll
+CREATE TABLE poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (poll_id, user_id)
+);
+
+CREATE INDEX poll_votes_option_idx ON poll_votes (option_id);
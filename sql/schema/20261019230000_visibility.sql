@@ -0,0 +1,59 @@
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id);
+
+-- Users mentioned in a chirp, who can see it whatever its visibility
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_mentions_user_idx ON chirp_mentions (user_id);
+
+-- Whether viewer can see a chirp: public chirps, their own, followers-only
+-- chirps of people they follow and chirps mentioning them, unless either
+-- side has blocked the other. A NULL viewer sees public chirps only.
+CREATE FUNCTION chirp_visible_to(target_chirp UUID, target_author UUID, target_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(
+        (
+            target_visibility = 'public'
+            OR target_author = viewer
+            OR (
+                target_visibility = 'followers'
+                AND EXISTS (
+                    SELECT 1
+                    FROM follows f
+                    WHERE f.follower_id = viewer
+                      AND f.followee_id = target_author
+                )
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM chirp_mentions cm
+                WHERE cm.chirp_id = target_chirp
+                  AND cm.user_id = viewer
+            )
+        )
+        AND (
+            viewer IS NULL
+            OR NOT EXISTS (
+                SELECT 1
+                FROM user_blocks b
+                WHERE (b.blocker_id = viewer AND b.blocked_id = target_author)
+                   OR (b.blocker_id = target_author AND b.blocked_id = viewer)
+            )
+        ),
+        FALSE
+    )
+$$;